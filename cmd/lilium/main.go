@@ -0,0 +1,157 @@
+// Command lilium manages lilium.yaml files.
+//
+//	lilium keygen
+//	lilium encrypt [-f lilium.yaml] [-key-file path] db.password server.admin.token
+//	lilium decrypt [-f lilium.yaml] [-key-file path] [path ...]
+//	lilium rotate  [-f lilium.yaml] [-key-file path] -new-key-file path
+//	lilium dump    [-f lilium.yaml] [-profile name] [-format yaml|json] [-no-sources] [-show-secrets]
+//
+// Keys come from -key-file, $LILIUM_CONFIG_KEY or $LILIUM_CONFIG_KEY_FILE.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spyder01/lilium-go/pkg/config"
+	"github.com/spyder01/lilium-go/pkg/logger"
+)
+
+const usage = `usage: lilium <command> [flags]
+
+commands:
+  keygen    print a new encryption key
+  encrypt   encrypt the values at the given paths in place
+  decrypt   decrypt values in place (all of them when no path is given)
+  rotate    re-encrypt every value with a new key
+  dump      print the effective config and where each value came from
+
+Run "lilium <command> -h" for the flags of a command.
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "lilium:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return fmt.Errorf("missing command")
+	}
+
+	cmd, args := args[0], args[1:]
+	fs := flag.NewFlagSet("lilium "+cmd, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	file := fs.String("f", "lilium.yaml", "config file to edit")
+	keyFile := fs.String("key-file", "", "key file (default $"+config.KeyEnv+" or $"+config.KeyFileEnv+")")
+
+	switch cmd {
+	case "keygen":
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		key, err := config.GenerateKey()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout, config.EncodeKey(key))
+		return nil
+
+	case "encrypt", "decrypt":
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		key, err := loadKey(*keyFile)
+		if err != nil {
+			return err
+		}
+		var n int
+		if cmd == "encrypt" {
+			if fs.NArg() == 0 {
+				return fmt.Errorf("encrypt: name at least one path, e.g. db.password")
+			}
+			n, err = config.EncryptFile(*file, key, fs.Args()...)
+		} else {
+			n, err = config.DecryptFile(*file, key, fs.Args()...)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "%sed %d value(s) in %s\n", cmd, n, *file)
+		return nil
+
+	case "rotate":
+		newKeyFile := fs.String("new-key-file", "", "file holding the new key")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if *newKeyFile == "" {
+			return fmt.Errorf("rotate: -new-key-file is required")
+		}
+		oldKey, err := loadKey(*keyFile)
+		if err != nil {
+			return err
+		}
+		newKey, err := config.ReadKeyFile(*newKeyFile)
+		if err != nil {
+			return err
+		}
+		n, err := config.RotateFile(*file, oldKey, newKey)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "rotated %d value(s) in %s\n", n, *file)
+		return nil
+
+	case "dump":
+		profile := fs.String("profile", "", "profile to load (default $"+config.ProfileEnv+")")
+		format := fs.String("format", "yaml", "output format: yaml or json")
+		noSources := fs.Bool("no-sources", false, "leave out where each value came from")
+		showSecrets := fs.Bool("show-secrets", false, "print secret values instead of masking them")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		opts := config.LoadOptions{Profile: *profile}
+		if *keyFile != "" {
+			key, err := config.ReadKeyFile(*keyFile)
+			if err != nil {
+				return err
+			}
+			opts.Key = key
+		}
+		cfg, err := config.LoadWithOptions(*file, opts)
+		if err != nil {
+			return err
+		}
+		out, err := config.Dump(cfg, config.DumpOptions{
+			Format:      config.DumpFormat(*format),
+			NoSources:   *noSources,
+			ShowSecrets: *showSecrets,
+			MaskKey:     logger.SensitiveKey(cfg.Logger.Redact),
+		})
+		if err != nil {
+			return err
+		}
+		_, err = stdout.Write(out)
+		return err
+
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(stdout, usage)
+		return nil
+	}
+
+	fmt.Fprint(stderr, usage)
+	return fmt.Errorf("unknown command %q", cmd)
+}
+
+func loadKey(path string) ([]byte, error) {
+	if path != "" {
+		return config.ReadKeyFile(path)
+	}
+	return config.LoadKey()
+}
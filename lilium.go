@@ -20,6 +20,12 @@ func LoadConfig(path string) *config.LiliumConfig {
 	return core.LoadLiliumConfig(path)
 }
 
+// LoadConfigFlags loads the config described by flags bound with
+// config.BindFlags, after the flag set has been parsed.
+func LoadConfigFlags(flags *config.Flags) *config.LiliumConfig {
+	return core.LoadLiliumConfigFlags(flags)
+}
+
 func New(config *config.LiliumConfig, ctx_ context.Context) *core.Lilium {
 	return core.New(config, ctx_)
 }
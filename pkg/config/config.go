@@ -3,19 +3,20 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
-	"github.com/spyder01/lilium-go/pkg/utils/env"
 	"gopkg.in/yaml.v3"
 )
 
 type CorsConfig struct {
-	Enabled          bool     `yaml:"enabled"`
-	Origins          []string `yaml:"origins"`
-	AllowedMethods   []string `yaml:"allowedMethods"`
-	AllowedHeaders   []string `yaml:"allowedHeaders"`
-	ExposedHeaders   []string `yaml:"exposedHeaders"`
-	AllowCredentials bool     `yaml:"allowCredentials"`
-	MaxAge           uint     `yaml:"maxAge"`
+	Enabled          bool     `yaml:"enabled" help:"apply server.cors to every route"`
+	Origins          []string `yaml:"origins" help:"allowed origins"`                           // exact, "*", or one wildcard such as "https://*.example.com"
+	OriginPatterns   []string `yaml:"originPatterns" help:"allowed origin regular expressions"` // regular expressions matched against the whole origin
+	AllowedMethods   []string `yaml:"allowedMethods" help:"methods allowed in cross-origin requests"`
+	AllowedHeaders   []string `yaml:"allowedHeaders" help:"request headers allowed in cross-origin requests"`
+	ExposedHeaders   []string `yaml:"exposedHeaders" help:"response headers exposed to browsers"`
+	AllowCredentials bool     `yaml:"allowCredentials" help:"allow cookies and credentials in cross-origin requests"`
+	MaxAge           uint     `yaml:"maxAge" help:"seconds browsers may cache preflight results"`
 }
 
 type StaticConfig struct {
@@ -23,75 +24,156 @@ type StaticConfig struct {
 	Directory string `yaml:"directory"` // e.g. "./public"
 }
 
+type AdminConfig struct {
+	Enabled bool   `yaml:"enabled" help:"serve the admin endpoints"`
+	Route   string `yaml:"route" help:"mount path of the admin endpoints"`            // e.g. "/_lilium"
+	Token   string `yaml:"token" help:"bearer token required by the admin endpoints"` // optional
+}
+
 type ServerConfig struct {
-	Port   uint           `yaml:"port"`
+	Port   uint           `yaml:"port" help:"HTTP port to listen on"`
 	Cors   *CorsConfig    `yaml:"cors"`
 	Static []StaticConfig `yaml:"static"` // <-- Add this
+	Admin  *AdminConfig   `yaml:"admin"`
+
+	DisposeTimeout time.Duration `yaml:"disposeTimeout" help:"limit for closing each provided dependency on shutdown"` // e.g. "5s"
 }
 
 type LogConfig struct {
-	ToFile       bool   `yaml:"toFile"`
-	FilePath     string `yaml:"filePath"`
-	ToStdout     bool   `yaml:"toStdout"`
-	Prefix       string `yaml:"prefix"`
-	Flags        int    `yaml:"flags"`
-	DebugEnabled bool   `yaml:"debugEnabled"`
+	ToFile       bool   `yaml:"toFile" help:"write logs to logger.filePath"`
+	FilePath     string `yaml:"filePath" help:"log file path"`
+	ToStdout     bool   `yaml:"toStdout" help:"write logs to stdout"`
+	Prefix       string `yaml:"prefix" help:"text put before every log line"`
+	Flags        int    `yaml:"flags" help:"standard library log flags"` // same bits as the standard library's log flags
+	DebugEnabled bool   `yaml:"debugEnabled" help:"log at debug level"`
+
+	Level        string            `yaml:"level" help:"minimum log level: trace, debug, info, warn, error or fatal"` // trace|debug|info|warn|error|fatal; overrides debugEnabled
+	Levels       map[string]string `yaml:"levels"`                                                                   // per-component overrides, e.g. {eventbus: debug}
+	StdoutFormat string            `yaml:"stdoutFormat" help:"stdout log format: json, console or logfmt"`           // json|console|logfmt
+	FileFormat   string            `yaml:"fileFormat" help:"log file format: json, console or logfmt"`               // json|console|logfmt
+
+	Sinks []SinkConfig `yaml:"sinks"` // additional destinations next to toStdout/toFile
+
+	Redact   *RedactConfig    `yaml:"redact"`
+	Access   *AccessLogConfig `yaml:"access"`
+	Sampling *SamplingConfig  `yaml:"sampling"`
+}
+
+type SamplingConfig struct {
+	Rates  map[string]uint `yaml:"rates"`                                                              // per level, keep 1 in N events, e.g. {debug: 10}
+	Burst  uint            `yaml:"burst" help:"identical messages written per period before sampling"` // identical messages written per period before sampling starts
+	Every  uint            `yaml:"every" help:"keep 1 in N identical messages after the burst"`        // after the burst, keep 1 in N identical messages
+	Period time.Duration   `yaml:"period" help:"sampling window"`                                      // burst window and summary interval, e.g. "10s"
+}
+
+type SinkConfig struct {
+	Type   string `yaml:"type"`   // stdout|stderr|file|syslog|tcp|udp
+	Format string `yaml:"format"` // json|console|logfmt
+	Level  string `yaml:"level"`  // minimum level written to this sink
+
+	Path string `yaml:"path"` // file
+
+	Network    string `yaml:"network"`    // syslog: udp|tcp|unix|unixgram
+	Address    string `yaml:"address"`    // host:port, or a socket path for unix networks
+	BufferSize int    `yaml:"bufferSize"` // events held while the connection is down
+
+	Facility string `yaml:"facility"` // syslog facility, e.g. "local0"
+	AppName  string `yaml:"appName"`  // syslog APP-NAME
+}
+
+type RedactConfig struct {
+	Keys       []string `yaml:"keys" help:"extra field, header and query names to mask in logs"`              // field, header and query names to mask
+	Patterns   []string `yaml:"patterns" help:"regular expressions to mask in logs"`                          // regular expressions masked in any text
+	Mask       string   `yaml:"mask" help:"replacement for masked values"`                                    // replacement, default "[REDACTED]"
+	NoDefaults bool     `yaml:"noDefaults" help:"drop the built-in redaction keys and card-number detection"` // drop the built-in keys and card-number pattern
+
+	Values []string `yaml:"-"` // exact values masked in any text; Load adds decrypted and resolved secrets
+}
+
+type AccessLogConfig struct {
+	LogQuery    bool `yaml:"logQuery" help:"include query strings in request logs"`
+	LogBody     bool `yaml:"logBody" help:"include request bodies in request logs"`      // request bodies; JSON bodies are redacted field by field
+	MaxBodySize int  `yaml:"maxBodySize" help:"bytes of request body captured"`          // bytes captured when logBody is on
+	Sample2xx   uint `yaml:"sample2xx" help:"keep 1 in N 2xx responses in request logs"` // keep 1 in N 2xx responses; other statuses are always logged
+
+	// Dedicated access log, written independently of the application log.
+	Enabled        bool     `yaml:"enabled" help:"write a dedicated access log"`
+	Format         string   `yaml:"format" help:"access log format: common, combined, json or template"` // common | combined | json | template
+	Template       string   `yaml:"template" help:"access log template"`                                 // e.g. "%h %r %s %b %D {X-Request-ID}i"
+	FilePath       string   `yaml:"filePath" help:"access log file path, - for stdout"`                  // "-" writes to stdout
+	MaxSizeMB      int      `yaml:"maxSizeMB" help:"megabytes before the access log is rotated"`         // rotate once the file grows past this size
+	MaxBackups     int      `yaml:"maxBackups" help:"rotated access log files to keep"`                  // rotated files kept as filePath.1 ... filePath.N
+	SkipPaths      []string `yaml:"skipPaths" help:"paths left out of the access log"`                   // exact paths, or prefixes ending in "*"
+	SkipExtensions []string `yaml:"skipExtensions" help:"file extensions left out of the access log"`    // e.g. [".css", ".js", ".png"]
 }
 
 type EnvironmentConfig struct {
-	EnableFile bool   `yaml:"enableFile"`
-	FilePath   string `yaml:"filePath"`
+	EnableFile bool   `yaml:"enableFile" help:"load .env files before the config"`
+	FilePath   string `yaml:"filePath" help:"base .env file"`                               // base file; {filePath}.{profile} and {filePath}.local are layered on top
+	Override   bool   `yaml:"override" help:"let .env files replace variables already set"` // let .env files replace variables already set in the process
 }
 
+// LoadEnv reads only the env section of the base config at path, so .env
+// files can be loaded before the rest of the config is expanded. It returns
+// nil when there is no env section.
 func LoadEnv(path string) (*EnvironmentConfig, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
 
-	cfg := &EnvironmentConfig{}
-	if err := yaml.Unmarshal([]byte(data), cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	root, err := parseDocument(FormatFor(path), data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var doc struct {
+		Env *EnvironmentConfig `yaml:"env"`
+	}
+	if root != nil {
+		if err := root.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
 	}
 
-	return cfg, nil
+	return doc.Env, nil
 }
 
 type LiliumConfig struct {
-	Name      string             `yaml:"name"`
+	Name      string             `yaml:"name" help:"application name"`
 	Server    *ServerConfig      `yaml:"server"`
 	Logger    *LogConfig         `yaml:"logger"`
-	LogRoutes bool               `yaml:"logRoutes"`
+	LogRoutes bool               `yaml:"logRoutes" help:"log registered routes at start-up"`
 	Env       *EnvironmentConfig `yaml:"env"`
 
 	Extras map[string]any `yaml:",inline"` // store unknown fields here
-}
 
-func Load(path string) (*LiliumConfig, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
+	Reload *ReloadConfig `yaml:"reload"`
 
-	expanded := env.ExpandEnvWithDefault(string(data))
+	Profile string   `yaml:"-"` // active profile, "" when none
+	Layers  []string `yaml:"-"` // files that were merged, base first
 
-	// --- First decode into a yaml.Node ---
-	var root yaml.Node
-	if err := yaml.Unmarshal([]byte(expanded), &root); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML: %w", err)
-	}
+	Warnings []string `yaml:"-"` // problems that did not stop loading, e.g. ignored environment overrides
 
-	// --- Second decode into typed struct ---
-	cfg := &LiliumConfig{}
-	if err := yaml.Unmarshal([]byte(expanded), cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML: %w", err)
-	}
+	path      string              // base file, for reloading
+	options   LoadOptions         // options the config was loaded with
+	positions map[string]Position // where each value was set, by dotted path
+	secrets   map[string]bool     // paths whose value came from a secret resolver
+	exprs     map[string]string   // ${...} text each expanded value was written as
+	defaults  map[string]bool     // paths filled in by applyDefaults
+	loaded    map[string]string   // scalar values as Load returned them, to spot later changes
+	typed     *extraCache         // decoded Extras sections, see Extra
+}
 
-	// --- Extract unknown fields ---
-	cfg.Extras = extractUnknownFields(&root, cfg)
+type ReloadConfig struct {
+	Enabled  bool          `yaml:"enabled" help:"reload the config when its files change"`
+	Interval time.Duration `yaml:"interval" help:"how often config files are checked"` // how often files are checked, e.g. "2s"
+}
 
-	applyDefaults(cfg)
-	return cfg, nil
+// Load reads path and any lilium.{profile}.yaml / lilium.local.yaml layers
+// next to it, with the profile taken from $LILIUM_PROFILE. See
+// LoadWithOptions.
+func Load(path string) (*LiliumConfig, error) {
+	return LoadWithOptions(path, LoadOptions{})
 }
 
 func extractUnknownFields(root *yaml.Node, cfg *LiliumConfig) map[string]any {
@@ -101,6 +183,7 @@ func extractUnknownFields(root *yaml.Node, cfg *LiliumConfig) map[string]any {
 		"logger":    {},
 		"logRoutes": {},
 		"env":       {},
+		"reload":    {},
 	}
 
 	extras := make(map[string]any)
@@ -120,18 +203,3 @@ func extractUnknownFields(root *yaml.Node, cfg *LiliumConfig) map[string]any {
 
 	return extras
 }
-
-func GetExtra[T any](c *LiliumConfig, key string, out *T) error {
-	extra, ok := c.Extras[key]
-	if !ok {
-		return fmt.Errorf("extra config not found: %s", key)
-	}
-	b, err := yaml.Marshal(extra)
-	if err != nil {
-		return fmt.Errorf("failed to marshal extra config: %w", err)
-	}
-	if err := yaml.Unmarshal(b, out); err != nil {
-		return fmt.Errorf("failed to unmarshal extra config: %w", err)
-	}
-	return nil
-}
@@ -34,8 +34,8 @@ logger:
 `
 	cfgFile := writeTempFile(t, "config.yaml", yamlContent)
 
-	// Act
-	cfg, err := Load(cfgFile)
+	// Act: /srv/app need not exist here
+	cfg, err := LoadWithOptions(cfgFile, LoadOptions{NoValidate: true})
 	if err != nil {
 		t.Fatalf("Load() returned error: %v", err)
 	}
@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 func applyDefaults(cfg *LiliumConfig) {
 	if cfg.Name == "" {
 		cfg.Name = "Lilium" // Default app name if none provided
@@ -14,6 +16,10 @@ func applyDefaults(cfg *LiliumConfig) {
 		cfg.Server.Port = 8080
 	}
 
+	if cfg.Server.DisposeTimeout == 0 {
+		cfg.Server.DisposeTimeout = 5 * time.Second
+	}
+
 	// Static array optional → do not override if empty
 
 	// ---------- CORS ----------
@@ -28,6 +34,27 @@ func applyDefaults(cfg *LiliumConfig) {
 		cfg.Server.Cors.MaxAge = 600 // seconds
 	}
 
+	// ---------- Admin ----------
+	if cfg.Server.Admin == nil {
+		cfg.Server.Admin = &AdminConfig{}
+	}
+
+	if cfg.Server.Admin.Route == "" {
+		cfg.Server.Admin.Route = "/_lilium"
+	}
+
+	// ---------- Extras ----------
+	applyExtraDefaults(cfg)
+
+	// ---------- Reload ----------
+	if cfg.Reload == nil {
+		cfg.Reload = &ReloadConfig{}
+	}
+
+	if cfg.Reload.Interval == 0 {
+		cfg.Reload.Interval = 2 * time.Second
+	}
+
 	// ---------- Logger ----------
 	if cfg.Logger == nil {
 		cfg.Logger = &LogConfig{}
@@ -38,10 +65,48 @@ func applyDefaults(cfg *LiliumConfig) {
 	}
 
 	// If no output target specified → default stdout
-	if !cfg.Logger.ToFile && !cfg.Logger.ToStdout {
+	if !cfg.Logger.ToFile && !cfg.Logger.ToStdout && len(cfg.Logger.Sinks) == 0 {
 		cfg.Logger.ToStdout = true
 	}
 
+	if cfg.Logger.StdoutFormat == "" {
+		cfg.Logger.StdoutFormat = "console"
+	}
+
+	if cfg.Logger.FileFormat == "" {
+		cfg.Logger.FileFormat = "json"
+	}
+
+	if cfg.Logger.Access == nil {
+		cfg.Logger.Access = &AccessLogConfig{}
+	}
+
+	if cfg.Logger.Access.MaxBodySize == 0 {
+		cfg.Logger.Access.MaxBodySize = 4096
+	}
+
+	if a := cfg.Logger.Access; a.Enabled {
+		if a.Format == "" {
+			a.Format = "combined"
+			if a.Template != "" {
+				a.Format = "template"
+			}
+		}
+		if a.FilePath == "" {
+			a.FilePath = "./logs/access.log"
+		}
+		if a.MaxSizeMB == 0 {
+			a.MaxSizeMB = 100
+		}
+		if a.MaxBackups == 0 {
+			a.MaxBackups = 5
+		}
+	}
+
+	if s := cfg.Logger.Sampling; s != nil && s.Period == 0 {
+		s.Period = time.Second
+	}
+
 	if cfg.Env == nil {
 		cfg.Env = &EnvironmentConfig{
 			EnableFile: false,
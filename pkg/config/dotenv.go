@@ -0,0 +1,263 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/spyder01/lilium-go/pkg/utils/env"
+)
+
+// DotenvOptions control which .env files LoadDotenv reads and how they
+// combine with the process environment.
+type DotenvOptions struct {
+	// Profile also reads {base}.{profile}, e.g. .env.prod.
+	Profile string
+
+	// NoLocal skips {base}.local.
+	NoLocal bool
+
+	// Override lets the files replace variables already set in the process.
+	// By default those are kept, so the real environment wins over .env.
+	Override bool
+}
+
+// DotenvPaths returns the .env files read for base, in merge order: the base
+// file, the profile file (if profile is set) and the local file. For ".env"
+// and profile "prod" these are .env, .env.prod and .env.local.
+func DotenvPaths(base, profile string, noLocal bool) []string {
+	paths := []string{base}
+	if profile != "" {
+		paths = append(paths, base+"."+profile)
+	}
+	if !noLocal {
+		paths = append(paths, base+".local")
+	}
+	return paths
+}
+
+// LoadDotenv reads the layers of base (see DotenvPaths) and sets the
+// variables they define in the process environment. Later files win over
+// earlier ones; missing files are skipped. It returns the files that were
+// read.
+func LoadDotenv(base string, opts DotenvOptions) ([]string, error) {
+	vars, loaded, err := ReadDotenv(base, opts)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range vars {
+		if _, set := os.LookupEnv(key); set && !opts.Override {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return loaded, nil
+}
+
+// ReadDotenv works like LoadDotenv but returns the variables instead of
+// setting them. Values may reference variables defined earlier in the same
+// or a previous file, or in the process environment.
+func ReadDotenv(base string, opts DotenvOptions) (map[string]string, []string, error) {
+	vars := make(map[string]string)
+	lookup := func(name string) (string, bool) {
+		if v, ok := os.LookupEnv(name); ok && !opts.Override {
+			return v, true
+		}
+		if v, ok := vars[name]; ok {
+			return v, true
+		}
+		return os.LookupEnv(name)
+	}
+
+	var loaded []string
+	for _, path := range DotenvPaths(base, opts.Profile, opts.NoLocal) {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := parseDotenv(path, data, vars, lookup); err != nil {
+			return nil, nil, err
+		}
+		loaded = append(loaded, path)
+	}
+	return vars, loaded, nil
+}
+
+// ParseDotenv reads one .env document from r. References are resolved
+// against earlier variables of the document, then the process environment.
+func ParseDotenv(r io.Reader) (map[string]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	vars := make(map[string]string)
+	lookup := func(name string) (string, bool) {
+		if v, ok := vars[name]; ok {
+			return v, true
+		}
+		return os.LookupEnv(name)
+	}
+	if err := parseDotenv(readerSource, data, vars, lookup); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+// parseDotenv adds the variables of data to vars. It understands
+//
+//	KEY=value                 unquoted, trailing " # comment" dropped
+//	export KEY=value          the export keyword is ignored
+//	KEY="a\nb ${OTHER}"       escapes and references; may span lines
+//	KEY='literal $text'       no escapes or references; may span lines
+//
+// References use the ${...} syntax of config files plus bare $VAR; $$ and
+// \$ escape a dollar sign.
+func parseDotenv(file string, data []byte, vars map[string]string, lookup func(string) (string, bool)) error {
+	p := &dotenvParser{file: file, src: string(bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))), line: 1}
+	for {
+		key, value, expand, line, err := p.next()
+		if err != nil {
+			return err
+		}
+		if key == "" {
+			return nil
+		}
+		if expand {
+			value, _, err = env.ExpandWith(value, env.ExpandOptions{Lookup: lookup, Bare: true})
+			if err != nil {
+				return fmt.Errorf("%s:%d: %s: %w", file, line, key, err)
+			}
+		}
+		vars[key] = value
+	}
+}
+
+type dotenvParser struct {
+	file string
+	src  string
+	pos  int
+	line int
+}
+
+func (p *dotenvParser) errorf(line int, format string, args ...any) error {
+	return fmt.Errorf("%s:%d: %s", p.file, line, fmt.Sprintf(format, args...))
+}
+
+// readLine returns the rest of the current line and moves past it.
+func (p *dotenvParser) readLine() string {
+	end := strings.IndexByte(p.src[p.pos:], '\n')
+	if end < 0 {
+		line := p.src[p.pos:]
+		p.pos = len(p.src)
+		return line
+	}
+	line := p.src[p.pos : p.pos+end]
+	p.pos += end + 1
+	p.line++
+	return line
+}
+
+// next returns the next assignment, or an empty key at the end of input.
+func (p *dotenvParser) next() (key, value string, expand bool, line int, err error) {
+	for p.pos < len(p.src) {
+		line = p.line
+		start := p.pos
+		text := strings.TrimSpace(p.readLine())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+
+		name, rest, ok := strings.Cut(text, "=")
+		key = strings.TrimSpace(name)
+		if !ok || !validDotenvKey(key) {
+			return "", "", false, 0, p.errorf(line, "expected KEY=value, got %q", text)
+		}
+
+		rest = strings.TrimLeft(rest, " \t")
+		if rest == "" || (rest[0] != '"' && rest[0] != '\'') {
+			if i := strings.Index(rest, " #"); i >= 0 {
+				rest = rest[:i]
+			}
+			return key, strings.TrimSpace(strings.ReplaceAll(rest, `\$`, "$$")), true, line, nil
+		}
+
+		// Quoted values may span lines: rewind to the opening quote.
+		quote := rest[0]
+		p.pos = start + strings.IndexByte(p.src[start:], '=') + 1
+		for p.src[p.pos] != quote {
+			p.pos++
+		}
+		p.pos++
+		p.line = line
+		value, err = p.quoted(quote, line)
+		if err != nil {
+			return "", "", false, 0, err
+		}
+		if tail := strings.TrimSpace(p.readLine()); tail != "" && !strings.HasPrefix(tail, "#") {
+			return "", "", false, 0, p.errorf(line, "%s: unexpected %q after closing quote", key, tail)
+		}
+		return key, value, quote == '"', line, nil
+	}
+	return "", "", false, 0, nil
+}
+
+// quoted reads up to the closing quote, counting lines and, for double
+// quotes, decoding escapes.
+func (p *dotenvParser) quoted(quote byte, line int) (string, error) {
+	var b strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		p.pos++
+		switch {
+		case c == quote:
+			return b.String(), nil
+		case c == '\n':
+			p.line++
+			b.WriteByte(c)
+		case c == '\\' && quote == '"' && p.pos < len(p.src):
+			e := p.src[p.pos]
+			p.pos++
+			switch e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '$':
+				b.WriteString("$$")
+			case '"', '\\':
+				b.WriteByte(e)
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", p.errorf(line, "unterminated %c-quoted value", quote)
+}
+
+func validDotenvKey(key string) bool {
+	if key == "" || key[0] >= '0' && key[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c != '_' && c != '.' && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
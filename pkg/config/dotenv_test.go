@@ -0,0 +1,117 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// unsetenv clears keys for the test and restores them afterwards.
+func unsetenv(t *testing.T, keys ...string) {
+	t.Helper()
+	for _, k := range keys {
+		t.Setenv(k, "")
+		os.Unsetenv(k)
+	}
+}
+
+func TestParseDotenv_Syntax(t *testing.T) {
+	t.Setenv("DOTENV_HOME", "/home/app")
+	vars, err := ParseDotenv(strings.NewReader(`# comment
+export NAME=lilium   # trailing comment
+EMPTY=
+URL=postgres://${DB_HOST:-localhost}:$DB_PORT/app
+DB_PORT=5432
+DSN="host=$DB_HOST port=${DB_PORT}"
+LITERAL='$NAME stays ${NAME}'
+ESCAPED="price: \$5, tab:\tend, quote: \""
+KEY="-----BEGIN KEY-----
+abc
+-----END KEY-----"
+DIR=$DOTENV_HOME/data
+`))
+	if err != nil {
+		t.Fatalf("ParseDotenv: %v", err)
+	}
+
+	want := map[string]string{
+		"NAME":    "lilium",
+		"EMPTY":   "",
+		"URL":     "postgres://localhost:/app",
+		"DSN":     "host= port=5432",
+		"LITERAL": "$NAME stays ${NAME}",
+		"ESCAPED": "price: $5, tab:\tend, quote: \"",
+		"KEY":     "-----BEGIN KEY-----\nabc\n-----END KEY-----",
+		"DIR":     "/home/app/data",
+	}
+	for k, v := range want {
+		if vars[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, vars[k])
+		}
+	}
+}
+
+func TestParseDotenv_Errors(t *testing.T) {
+	cases := map[string]string{
+		"NAME lilium\n":          "<reader>:1: expected KEY=value",
+		"A=1\nB=\"open\nstill\n": "<reader>:2: unterminated \"-quoted value",
+		"A='x' y\n":              "<reader>:1: A: unexpected \"y\" after closing quote",
+		"A=${B:?is required}\n":  "<reader>:1: A: B: is required",
+	}
+	for content, want := range cases {
+		_, err := ParseDotenv(strings.NewReader(content))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: expected %q, got %v", content, want, err)
+		}
+	}
+}
+
+func TestLoadDotenv_Layers(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, ".env")
+	files := map[string]string{
+		".env":       "APP_NAME=base\nAPP_HOST=localhost\nAPP_URL=http://$APP_HOST\nAPP_SHELL=from-file\n",
+		".env.prod":  "APP_HOST=prod.example\nAPP_URL=https://$APP_HOST\n",
+		".env.local": "APP_NAME=local\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	unsetenv(t, "APP_NAME", "APP_HOST", "APP_URL")
+	t.Setenv("APP_SHELL", "from-process")
+
+	loaded, err := LoadDotenv(base, DotenvOptions{Profile: "prod"})
+	if err != nil {
+		t.Fatalf("LoadDotenv: %v", err)
+	}
+	if len(loaded) != 3 {
+		t.Errorf("expected three files, got %v", loaded)
+	}
+	for k, v := range map[string]string{
+		"APP_NAME":  "local",
+		"APP_HOST":  "prod.example",
+		"APP_URL":   "https://prod.example",
+		"APP_SHELL": "from-process",
+	} {
+		if got := os.Getenv(k); got != v {
+			t.Errorf("%s: expected %q, got %q", k, v, got)
+		}
+	}
+
+	if _, err := LoadDotenv(base, DotenvOptions{Override: true}); err != nil {
+		t.Fatalf("LoadDotenv: %v", err)
+	}
+	if got := os.Getenv("APP_SHELL"); got != "from-file" {
+		t.Errorf("expected Override to replace process variables, got %q", got)
+	}
+}
+
+func TestLoadDotenv_MissingFiles(t *testing.T) {
+	loaded, err := LoadDotenv(filepath.Join(t.TempDir(), ".env"), DotenvOptions{Profile: "prod"})
+	if err != nil || len(loaded) != 0 {
+		t.Fatalf("expected missing files to be skipped, got %v, %v", loaded, err)
+	}
+}
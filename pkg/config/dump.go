@@ -0,0 +1,200 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type DumpFormat string
+
+const (
+	DumpYAML DumpFormat = "yaml"
+	DumpJSON DumpFormat = "json"
+
+	DefaultDumpMask = "[REDACTED]"
+)
+
+// Sources reported by Dump besides file positions and environment
+// variables.
+const (
+	SourceDefault  = "default"  // filled in by applyDefaults
+	SourceOverride = "override" // changed in code after loading
+	SourceUnset    = "unset"    // zero value, set nowhere
+)
+
+type DumpOptions struct {
+	Format      DumpFormat            // yaml (default) or json
+	NoSources   bool                  // leave out where each value came from
+	ShowSecrets bool                  // print secret values instead of masking them
+	Mask        string                // replacement for secrets, default "[REDACTED]"
+	MaskKey     func(key string) bool // also mask values under matching keys, e.g. Redactor.MatchKey
+}
+
+// DumpEntry is one value of a JSON dump.
+type DumpEntry struct {
+	Path   string `json:"path"`
+	Value  any    `json:"value"`
+	Source string `json:"source,omitempty"`
+	Secret bool   `json:"secret,omitempty"`
+}
+
+// alwaysMasked lists built-in values that are secret wherever they were set.
+var alwaysMasked = map[string]bool{
+	"server.admin.token": true,
+}
+
+// Dump renders the effective config, including Extras and defaults. Each
+// value is annotated with where it came from: a file position (with the
+// ${...} reference it was expanded from), "env $VAR", "flag --name", "default",
+// "override" or "unset". Secrets are masked unless opts.ShowSecrets is set.
+//
+// YAML dumps carry the sources as line comments; JSON dumps are a list of
+// DumpEntry values.
+func Dump(cfg *LiliumConfig, opts DumpOptions) ([]byte, error) {
+	if opts.Mask == "" {
+		opts.Mask = DefaultDumpMask
+	}
+
+	var root yaml.Node
+	if err := root.Encode(cfg); err != nil {
+		return nil, err
+	}
+
+	var entries []DumpEntry
+	err := walkScalars(&root, "", func(n *yaml.Node, path string) error {
+		source := ""
+		if !opts.NoSources {
+			source = cfg.source(path, n.Value)
+			n.LineComment = source
+		}
+		secret := cfg.secret(path, opts.MaskKey)
+		if secret && !opts.ShowSecrets && n.Value != "" {
+			n.Value, n.Tag, n.Style = opts.Mask, "!!str", 0
+		}
+		if opts.Format == DumpJSON {
+			var v any
+			if err := n.Decode(&v); err != nil {
+				return err
+			}
+			entries = append(entries, DumpEntry{Path: path, Value: v, Source: source, Secret: secret})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch opts.Format {
+	case DumpJSON:
+		return json.MarshalIndent(entries, "", "  ")
+	case "", DumpYAML:
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(&root); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("unknown dump format %q", opts.Format)
+}
+
+func (c *LiliumConfig) secret(path string, maskKey func(string) bool) bool {
+	if c.IsSecret(path) || alwaysMasked[path] {
+		return true
+	}
+	if maskKey == nil {
+		return false
+	}
+	key := path[strings.LastIndex(path, ".")+1:]
+	if i := strings.IndexByte(key, '['); i >= 0 {
+		key = key[:i]
+	}
+	return maskKey(key)
+}
+
+// source describes where the value at path came from; value is its current
+// form, to tell values changed after loading.
+func (c *LiliumConfig) source(path, value string) string {
+	if c.loaded == nil {
+		return ""
+	}
+	if loaded, ok := c.loaded[path]; !ok || loaded != value {
+		return SourceOverride
+	}
+	if pos, ok := c.positions[path]; ok {
+		if strings.HasPrefix(pos.File, "$") {
+			return "env " + pos.File
+		}
+		if strings.HasPrefix(pos.File, "--") {
+			return "flag " + pos.File
+		}
+		if expr, ok := c.exprs[path]; ok {
+			return pos.String() + " " + expr
+		}
+		return pos.String()
+	}
+	if c.defaults[path] {
+		return SourceDefault
+	}
+	return SourceUnset
+}
+
+// flattenConfig returns every scalar of cfg, as YAML would write it, by
+// dotted path.
+func flattenConfig(cfg *LiliumConfig) map[string]string {
+	var root yaml.Node
+	out := make(map[string]string)
+	if err := root.Encode(cfg); err != nil {
+		return out
+	}
+	_ = walkScalars(&root, "", func(n *yaml.Node, path string) error {
+		out[path] = n.Value
+		return nil
+	})
+	return out
+}
+
+func isZeroScalar(v string) bool {
+	switch v {
+	case "", "0", "false", "null", "0s":
+		return true
+	}
+	return false
+}
+
+// walkScalars calls fn for every scalar below n with its dotted path.
+func walkScalars(n *yaml.Node, path string, fn func(n *yaml.Node, path string) error) error {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			if err := walkScalars(c, path, fn); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if err := walkScalars(n.Content[i+1], joinPath(path, n.Content[i].Value), fn); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for i, item := range n.Content {
+			if err := walkScalars(item, fmt.Sprintf("%s[%d]", path, i), fn); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		if path != "" {
+			return fn(n, path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDump_Sources(t *testing.T) {
+	path := writeLayers(t, map[string]string{
+		"lilium.yaml": `name: demo
+server:
+  port: ${DUMP_TEST_PORT:8080}
+  cors:
+    maxAge: 60
+`,
+	})
+	t.Setenv("LILIUM_SERVER__CORS__MAX_AGE", "120")
+
+	cfg, err := LoadWithOptions(path, LoadOptions{NoLocal: true})
+	if err != nil {
+		t.Fatalf("LoadWithOptions: %v", err)
+	}
+	cfg.LogRoutes = true
+
+	out, err := Dump(cfg, DumpOptions{})
+	if err != nil {
+		t.Fatalf("Dump: %v", err)
+	}
+	for _, want := range []string{
+		"name: demo # " + path + ":1:7",
+		"port: 8080 # " + path + ":3:9 ${DUMP_TEST_PORT:8080}",
+		"maxAge: 120 # env $LILIUM_SERVER__CORS__MAX_AGE",
+		"interval: 2s # default",
+		"logRoutes: true # override",
+		"debugEnabled: false # unset",
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+}
+
+func TestDump_MasksSecrets(t *testing.T) {
+	path := writeLayers(t, map[string]string{
+		"lilium.yaml": `server:
+  admin:
+    token: admin-token
+db:
+  password: ${base64:aHVudGVyMg==}
+  apiKey: plain-key
+  host: localhost
+`,
+	})
+	cfg, err := LoadWithOptions(path, LoadOptions{NoLocal: true, NoEnv: true})
+	if err != nil {
+		t.Fatalf("LoadWithOptions: %v", err)
+	}
+
+	out, err := Dump(cfg, DumpOptions{
+		Format:  DumpJSON,
+		MaskKey: func(key string) bool { return key == "apiKey" },
+	})
+	if err != nil {
+		t.Fatalf("Dump: %v", err)
+	}
+	var entries []DumpEntry
+	if err := json.Unmarshal(out, &entries); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out)
+	}
+	values := make(map[string]DumpEntry)
+	for _, e := range entries {
+		values[e.Path] = e
+	}
+	for _, p := range []string{"server.admin.token", "db.password", "db.apiKey"} {
+		if e := values[p]; e.Value != DefaultDumpMask || !e.Secret {
+			t.Errorf("%s: expected a masked secret, got %+v", p, e)
+		}
+	}
+	if e := values["db.host"]; e.Value != "localhost" || e.Secret {
+		t.Errorf("db.host: unexpected %+v", e)
+	}
+	if e := values["db.password"]; e.Source != path+":5:13 ${base64:aHVudGVyMg==}" {
+		t.Errorf("db.password: unexpected source %q", e.Source)
+	}
+
+	out, err = Dump(cfg, DumpOptions{ShowSecrets: true, NoSources: true})
+	if err != nil {
+		t.Fatalf("Dump: %v", err)
+	}
+	if !strings.Contains(string(out), "password: hunter2\n") || strings.Contains(string(out), "#") {
+		t.Errorf("expected plain secrets without sources, got\n%s", out)
+	}
+}
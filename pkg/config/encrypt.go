@@ -0,0 +1,346 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// KeyEnv holds the base64-encoded 32-byte key for ENC[...] values.
+	KeyEnv = "LILIUM_CONFIG_KEY"
+	// KeyFileEnv names a file holding the key, used when KeyEnv is unset.
+	KeyFileEnv = "LILIUM_CONFIG_KEY_FILE"
+
+	encAlgorithm = "aes256-gcm"
+	encPrefix    = "ENC[" + encAlgorithm + ","
+	keySize      = 32
+)
+
+// ErrNoKey is returned when an encrypted value is found but neither
+// LoadOptions.Key, $LILIUM_CONFIG_KEY nor $LILIUM_CONFIG_KEY_FILE is set.
+var ErrNoKey = errors.New("no decryption key: set $" + KeyEnv + " or $" + KeyFileEnv)
+
+// GenerateKey returns a new random key for Encrypt.
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// EncodeKey returns key in the form expected by $LILIUM_CONFIG_KEY.
+func EncodeKey(key []byte) string {
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+// ParseKey decodes a base64 key as written by EncodeKey. Surrounding
+// whitespace is ignored, so key files may end with a newline.
+func ParseKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("invalid key: want %d bytes, got %d", keySize, len(key))
+	}
+	return key, nil
+}
+
+// ReadKeyFile reads a key written by EncodeKey from path.
+func ReadKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ParseKey(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// LoadKey returns the key from $LILIUM_CONFIG_KEY, or from the file named
+// by $LILIUM_CONFIG_KEY_FILE.
+func LoadKey() ([]byte, error) {
+	if s := os.Getenv(KeyEnv); s != "" {
+		key, err := ParseKey(s)
+		if err != nil {
+			return nil, fmt.Errorf("$%s: %w", KeyEnv, err)
+		}
+		return key, nil
+	}
+	if path := os.Getenv(KeyFileEnv); path != "" {
+		return ReadKeyFile(path)
+	}
+	return nil, ErrNoKey
+}
+
+// IsEncrypted reports whether s is an ENC[...] value.
+func IsEncrypted(s string) bool {
+	return strings.HasPrefix(s, "ENC[") && strings.HasSuffix(s, "]")
+}
+
+// Encrypt seals plaintext with key and returns it as
+// ENC[aes256-gcm,<base64 nonce and ciphertext>].
+func Encrypt(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encPrefix + base64.StdEncoding.EncodeToString(sealed) + "]", nil
+}
+
+// Decrypt opens a value produced by Encrypt. Errors never include the
+// plaintext.
+func Decrypt(key []byte, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", errors.New("not an ENC[...] value")
+	}
+	if !strings.HasPrefix(value, encPrefix) {
+		return "", fmt.Errorf("unsupported encryption, want ENC[%s,...]", encAlgorithm)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSuffix(strings.TrimPrefix(value, encPrefix), "]"))
+	if err != nil {
+		return "", fmt.Errorf("malformed encrypted value: %w", err)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("malformed encrypted value: too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.New("cannot decrypt value: wrong key or corrupted data")
+	}
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("invalid key: want %d bytes, got %d", keySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// keyring hands out the decryption key during a load. The key is only
+// looked up once an encrypted value is found, so configs without ENC[...]
+// values never need one.
+type keyring struct {
+	key    []byte
+	err    error
+	loaded bool
+}
+
+func newKeyring(key []byte) *keyring {
+	return &keyring{key: key, loaded: key != nil}
+}
+
+func (k *keyring) decrypt(value string) (string, error) {
+	if !k.loaded {
+		k.key, k.err = LoadKey()
+		k.loaded = true
+	}
+	if k.err != nil {
+		return "", k.err
+	}
+	return Decrypt(k.key, value)
+}
+
+// EncryptFile encrypts the values at paths (dotted, as in
+// "db.password" or "server.static[0].directory") in the YAML file at path,
+// rewriting it in place with comments preserved. A path naming a mapping
+// or list encrypts every value below it; values that are already encrypted
+// are left alone. It returns the number of values encrypted.
+func EncryptFile(path string, key []byte, paths ...string) (int, error) {
+	if len(paths) == 0 {
+		return 0, errors.New("no paths to encrypt")
+	}
+	return editFile(path, paths, func(n *yaml.Node) (bool, error) {
+		if IsEncrypted(n.Value) {
+			return false, nil
+		}
+		enc, err := Encrypt(key, n.Value)
+		if err != nil {
+			return false, err
+		}
+		n.Value, n.Tag, n.Style = enc, "!!str", 0
+		return true, nil
+	})
+}
+
+// DecryptFile replaces ENC[...] values in the file at path with their
+// plaintext, limited to paths when any are given. It returns the number of
+// values decrypted.
+func DecryptFile(path string, key []byte, paths ...string) (int, error) {
+	return editFile(path, paths, func(n *yaml.Node) (bool, error) {
+		if !IsEncrypted(n.Value) {
+			return false, nil
+		}
+		plain, err := Decrypt(key, n.Value)
+		if err != nil {
+			return false, err
+		}
+		n.Value, n.Tag, n.Style = plain, "", 0
+		return true, nil
+	})
+}
+
+// RotateFile re-encrypts every ENC[...] value in the file at path from
+// oldKey to newKey. Nothing is written unless every value decrypts. It
+// returns the number of values rotated.
+func RotateFile(path string, oldKey, newKey []byte) (int, error) {
+	if _, err := newGCM(newKey); err != nil {
+		return 0, err
+	}
+	return editFile(path, nil, func(n *yaml.Node) (bool, error) {
+		if !IsEncrypted(n.Value) {
+			return false, nil
+		}
+		plain, err := Decrypt(oldKey, n.Value)
+		if err != nil {
+			return false, err
+		}
+		if n.Value, err = Encrypt(newKey, plain); err != nil {
+			return false, err
+		}
+		return true, nil
+	})
+}
+
+// editFile applies fn to the scalars of the YAML file at path that lie
+// below one of paths (all scalars when paths is empty), then writes the
+// file back if anything changed. The document is edited as a yaml.Node so
+// comments and key order survive.
+func editFile(path string, paths []string, fn func(n *yaml.Node) (bool, error)) (int, error) {
+	if FormatFor(path) != YAML {
+		return 0, fmt.Errorf("%s: only YAML files can be edited in place", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return 0, fmt.Errorf("%s: failed to parse YAML: %w", path, err)
+	}
+
+	e := &fileEdit{file: path, paths: paths, found: make(map[string]bool), fn: fn}
+	if len(doc.Content) > 0 {
+		if err := e.walk(doc.Content[0], "", len(paths) == 0); err != nil {
+			return 0, err
+		}
+	}
+	for _, p := range paths {
+		if !e.found[p] {
+			return 0, fmt.Errorf("%s: %s: no such key", path, p)
+		}
+	}
+	if e.changed == 0 {
+		return 0, nil
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(detectIndent(data))
+	if err := enc.Encode(&doc); err != nil {
+		return 0, err
+	}
+	if err := enc.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), info.Mode().Perm()); err != nil {
+		return 0, err
+	}
+	return e.changed, nil
+}
+
+type fileEdit struct {
+	file    string
+	paths   []string
+	found   map[string]bool
+	fn      func(n *yaml.Node) (bool, error)
+	changed int
+}
+
+func (e *fileEdit) walk(n *yaml.Node, path string, selected bool) error {
+	for _, p := range e.paths {
+		if p == path {
+			e.found[p] = true
+			selected = true
+		}
+	}
+
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if err := e.walk(n.Content[i+1], joinPath(path, n.Content[i].Value), selected); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for i, item := range n.Content {
+			if err := e.walk(item, fmt.Sprintf("%s[%d]", path, i), selected); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		if !selected || n.Tag == "!!null" {
+			return nil
+		}
+		changed, err := e.fn(n)
+		if err != nil {
+			return &FieldError{Pos: Position{File: e.file, Line: n.Line, Column: n.Column}, Path: path, Msg: err.Error()}
+		}
+		if changed {
+			e.changed++
+		}
+	}
+	return nil
+}
+
+// detectIndent returns the smallest indentation used in a YAML document, so
+// rewritten files keep their layout. It defaults to 2.
+func detectIndent(data []byte) int {
+	indent := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if n := len(line) - len(trimmed); n > 0 && (indent == 0 || n < indent) {
+			indent = n
+		}
+	}
+	if indent < 2 {
+		return 2
+	}
+	return indent
+}
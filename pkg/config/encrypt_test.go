@@ -0,0 +1,178 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testKey(t *testing.T) []byte {
+	t.Helper()
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	return key
+}
+
+func TestEncrypt_RoundTrip(t *testing.T) {
+	key := testKey(t)
+	enc, err := Encrypt(key, "hunter2")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if !strings.HasPrefix(enc, "ENC[aes256-gcm,") || !IsEncrypted(enc) {
+		t.Fatalf("unexpected ciphertext %q", enc)
+	}
+	if plain, err := Decrypt(key, enc); err != nil || plain != "hunter2" {
+		t.Fatalf("Decrypt = %q, %v", plain, err)
+	}
+
+	_, err = Decrypt(testKey(t), enc)
+	if err == nil || strings.Contains(err.Error(), "hunter2") {
+		t.Fatalf("expected an error without the plaintext for a wrong key, got %v", err)
+	}
+	if _, err := Decrypt(key, "ENC[rot13,abc]"); err == nil {
+		t.Fatal("expected an error for an unknown algorithm")
+	}
+}
+
+func TestLoad_DecryptsValues(t *testing.T) {
+	key := testKey(t)
+	password, _ := Encrypt(key, "hunter2")
+	port, _ := Encrypt(key, "9090")
+
+	path := writeLayers(t, map[string]string{
+		"lilium.yaml": "server:\n  port: " + port + "\ndb:\n  password: " + password + "\n",
+	})
+
+	t.Setenv(KeyEnv, EncodeKey(key))
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Server.Port != 9090 {
+		t.Errorf("port = %d, want 9090", cfg.Server.Port)
+	}
+	db, _ := cfg.Extras["db"].(map[string]any)
+	if db["password"] != "hunter2" {
+		t.Errorf("password = %v", db["password"])
+	}
+	if !cfg.IsSecret("db.password") || !cfg.IsSecret("server.port") {
+		t.Error("decrypted values should be marked secret")
+	}
+	if cfg.Logger.Redact == nil || !contains(cfg.Logger.Redact.Values, "hunter2") {
+		t.Errorf("decrypted values should be redacted from logs, got %+v", cfg.Logger.Redact)
+	}
+	if !contains(cfg.Logger.Redact.Values, "9090") {
+		t.Error("short secrets should be redacted too")
+	}
+	if cfg.Logger.Redact.NoDefaults {
+		t.Error("secrets should not turn off the default redaction keys")
+	}
+}
+
+func TestLoad_EncryptedValueErrors(t *testing.T) {
+	key := testKey(t)
+	enc, _ := Encrypt(key, "not-a-port")
+	path := writeLayers(t, map[string]string{
+		"lilium.yaml": "server:\n  port: " + enc + "\n",
+	})
+
+	t.Setenv(KeyEnv, "")
+	t.Setenv(KeyFileEnv, "")
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), KeyEnv) {
+		t.Fatalf("expected a missing key error, got %v", err)
+	}
+
+	_, err := LoadWithOptions(path, LoadOptions{Key: key})
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+	if strings.Contains(err.Error(), "not-a-port") || verrs[0].Path != "server.port" {
+		t.Fatalf("error should name the path but not the value: %v", err)
+	}
+}
+
+func TestLoad_KeyFile(t *testing.T) {
+	key := testKey(t)
+	enc, _ := Encrypt(key, "from-file")
+	path := writeLayers(t, map[string]string{
+		"lilium.yaml": "name: " + enc + "\n",
+		"key":         EncodeKey(key) + "\n",
+	})
+	keyPath := filepath.Join(filepath.Dir(path), "key")
+
+	t.Setenv(KeyEnv, "")
+	t.Setenv(KeyFileEnv, keyPath)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Name != "from-file" {
+		t.Fatalf("name = %q", cfg.Name)
+	}
+}
+
+func TestEncryptFile_PreservesCommentsAndRotates(t *testing.T) {
+	path := writeLayers(t, map[string]string{"lilium.yaml": `name: demo # app name
+db:
+  # credentials
+  password: "hunter2"
+  user: admin
+`})
+
+	oldKey, newKey := testKey(t), testKey(t)
+	if n, err := EncryptFile(path, oldKey, "db.password"); err != nil || n != 1 {
+		t.Fatalf("EncryptFile = %d, %v", n, err)
+	}
+	data := readFile(t, path)
+	for _, want := range []string{"# app name", "# credentials", "user: admin", "password: ENC[aes256-gcm,"} {
+		if !strings.Contains(data, want) {
+			t.Errorf("missing %q in\n%s", want, data)
+		}
+	}
+	if strings.Contains(data, "hunter2") {
+		t.Fatalf("plaintext left in file:\n%s", data)
+	}
+	if n, _ := EncryptFile(path, oldKey, "db.password"); n != 0 {
+		t.Errorf("encrypted values should not be encrypted twice")
+	}
+	if _, err := EncryptFile(path, oldKey, "db.missing"); err == nil {
+		t.Error("expected an error for an unknown path")
+	}
+
+	if n, err := RotateFile(path, oldKey, newKey); err != nil || n != 1 {
+		t.Fatalf("RotateFile = %d, %v", n, err)
+	}
+	if _, err := DecryptFile(path, oldKey); err == nil {
+		t.Fatal("old key should no longer decrypt")
+	}
+	if n, err := DecryptFile(path, newKey); err != nil || n != 1 {
+		t.Fatalf("DecryptFile = %d, %v", n, err)
+	}
+	if data := readFile(t, path); !strings.Contains(data, "password: hunter2") || !strings.Contains(data, "# credentials") {
+		t.Fatalf("unexpected file after decrypt:\n%s", data)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
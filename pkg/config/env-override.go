@@ -0,0 +1,349 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DefaultEnvPrefix    = "LILIUM_"
+	DefaultEnvSeparator = "__"
+)
+
+// envVar is one override variable, split into path segments.
+type envVar struct {
+	name     string
+	value    string
+	segments [][]string
+}
+
+// envOverrides collects every variable starting with the configured prefix,
+// sorted by name. Path segments are split on the separator; inside a
+// segment, single underscores are matched against known keys later, so
+// LILIUM_SERVER_PORT and LILIUM_SERVER__PORT both set server.port.
+func envOverrides(opts *LoadOptions) []envVar {
+	prefix, sep := opts.EnvPrefix, opts.EnvSeparator
+	if prefix == "" {
+		prefix = DefaultEnvPrefix
+	}
+	if sep == "" {
+		sep = DefaultEnvSeparator
+	}
+
+	var out []envVar
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) || reservedEnv[name] {
+			continue
+		}
+
+		v := envVar{name: name, value: value}
+		for _, seg := range strings.Split(strings.TrimPrefix(name, prefix), sep) {
+			if seg == "" {
+				continue
+			}
+			if sep == "_" {
+				v.segments = append(v.segments, []string{seg})
+			} else {
+				v.segments = append(v.segments, strings.Split(seg, "_"))
+			}
+		}
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].name < out[j].name })
+	return out
+}
+
+// applyEnvOverrides merges every override variable into root. Values may
+// use ${...} references like files do; each override is checked against the
+// schema on its own so errors name the variable. Variables that match no
+// built-in field, registered Extras section or key of the files are skipped
+// with a warning, since unrelated variables may share the prefix.
+func applyEnvOverrides(root *yaml.Node, opts *LoadOptions, st *loadState) (*yaml.Node, error) {
+	for _, v := range envOverrides(opts) {
+		next, err := applyOverride(root, v.segments, v.value, "$"+v.name, st)
+		var unknown *unknownKeyError
+		if errors.As(err, &unknown) {
+			st.warnings = append(st.warnings, fmt.Sprintf("$%s ignored: %v", v.name, unknown))
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		root = next
+	}
+	return root, nil
+}
+
+// unknownKeyError is returned by buildOverride for a path that names no
+// known config key.
+type unknownKeyError struct {
+	path, key string
+}
+
+func (e *unknownKeyError) Error() string {
+	return fmt.Sprintf("%s: no config key matches %q", orRoot(e.path), e.key)
+}
+
+// applyOverride sets value at the path described by segments, checked and
+// recorded like a layer of its own named source.
+func applyOverride(root *yaml.Node, segments [][]string, value, source string, st *loadState) (*yaml.Node, error) {
+	node, err := buildOverride(segments, reflect.TypeOf(LiliumConfig{}), root, value, "")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+
+	if err := expandNodes(node, "", source, st.files, st); err != nil {
+		return nil, err
+	}
+	if err := checkLayer(node, source, st.secrets); err != nil {
+		return nil, err
+	}
+	tagNew(node, source, st.files)
+
+	if root == nil {
+		return node, nil
+	}
+	// Overrides always replace lists, whatever the layer policy.
+	return mergeNodes(root, node, "", &LoadOptions{}), nil
+}
+
+// tagNew records source for the nodes of n that are not already known, so
+// values carried over from files keep their original position.
+func tagNew(n *yaml.Node, source string, files map[*yaml.Node]string) {
+	if _, ok := files[n]; ok {
+		return
+	}
+	files[n] = source
+	for _, c := range n.Content {
+		tagNew(c, source, files)
+	}
+}
+
+// reservedEnv lists variables with the override prefix that configure
+// loading itself rather than a config value.
+var reservedEnv = map[string]bool{
+	ProfileEnv: true,
+	KeyEnv:     true,
+	KeyFileEnv: true,
+}
+
+// buildOverride returns a tree that sets value at the path described by
+// segments, shaped after t and the existing node.
+func buildOverride(segments [][]string, t reflect.Type, existing *yaml.Node, value, path string) (*yaml.Node, error) {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if existing != nil && existing.Kind == yaml.AliasNode {
+		existing = existing.Alias
+	}
+	if len(segments) == 0 {
+		return envValueNode(t, value), nil
+	}
+
+	// List elements are addressed by index: LILIUM_SERVER__STATIC__0__DIRECTORY.
+	isList := t != nil && t.Kind() == reflect.Slice && !isScalarList(t)
+	isList = isList || (t == nil || t.Kind() == reflect.Interface) && existing != nil && existing.Kind == yaml.SequenceNode
+	if isList {
+		idx, err := strconv.Atoi(segments[0][0])
+		if err != nil || len(segments[0]) != 1 {
+			return nil, fmt.Errorf("%s: expected a list index, got %q", path, strings.Join(segments[0], "_"))
+		}
+		var items []*yaml.Node
+		if existing != nil && existing.Kind == yaml.SequenceNode {
+			items = existing.Content
+		}
+		if idx > len(items) {
+			return nil, fmt.Errorf("%s: index %d is past the end of the list", path, idx)
+		}
+
+		var elemType reflect.Type
+		if t != nil && t.Kind() == reflect.Slice {
+			elemType = t.Elem()
+		}
+		var current *yaml.Node
+		if idx < len(items) {
+			current = items[idx]
+		}
+		child, err := buildOverride(segments[1:], elemType, current, value, fmt.Sprintf("%s[%d]", path, idx))
+		if err != nil {
+			return nil, err
+		}
+
+		// Lists are replaced as a whole, so carry the other elements over.
+		seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		seq.Content = append(seq.Content, items...)
+		if idx == len(items) {
+			seq.Content = append(seq.Content, child)
+		} else {
+			seq.Content[idx] = overlayNode(items[idx], child)
+		}
+		return seq, nil
+	}
+
+	var candidates map[string]reflect.Type
+	strict := false
+	if t != nil && t.Kind() == reflect.Struct {
+		fields, inline := yamlFields(t)
+		candidates = make(map[string]reflect.Type, len(fields))
+		for name, f := range fields {
+			candidates[name] = f.Type
+		}
+		strict = !inline
+		if inline && path == "" {
+			// Registered Extras sections are typed like built-in ones.
+			for key, schema := range registeredSchemas() {
+				candidates[key] = schema
+			}
+		}
+	}
+	if existing != nil && existing.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(existing.Content); i += 2 {
+			if _, ok := candidates[existing.Content[i].Value]; !ok {
+				if candidates == nil {
+					candidates = make(map[string]reflect.Type)
+				}
+				candidates[existing.Content[i].Value] = elemType(t)
+			}
+		}
+	}
+
+	key, childType, rest, ok := matchKey(segments, candidates)
+	if !ok {
+		// Only maps below the top level take new keys; a new top-level key
+		// would be an Extras section nobody declared.
+		if strict || path == "" {
+			return nil, &unknownKeyError{path: path, key: strings.Join(segments[0], "_")}
+		}
+		key = strings.ToLower(strings.Join(segments[0], "_"))
+		childType, rest = elemType(t), segments[1:]
+	}
+
+	var current *yaml.Node
+	if existing != nil && existing.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(existing.Content); i += 2 {
+			if existing.Content[i].Value == key {
+				current = existing.Content[i+1]
+				break
+			}
+		}
+	}
+
+	child, err := buildOverride(rest, childType, current, value, joinPath(path, key))
+	if err != nil {
+		return nil, err
+	}
+	return &yaml.Node{
+		Kind:    yaml.MappingNode,
+		Tag:     "!!map",
+		Content: []*yaml.Node{{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, child},
+	}, nil
+}
+
+// matchKey finds the key named by the longest run of tokens at the start of
+// the first segment, ignoring case, '_' and '-'. The unmatched tokens of
+// that segment become a segment of their own.
+func matchKey(segments [][]string, candidates map[string]reflect.Type) (string, reflect.Type, [][]string, bool) {
+	tokens := segments[0]
+	byNorm := make(map[string]string, len(candidates))
+	for name := range candidates {
+		byNorm[normalizeEnvKey(name)] = name
+	}
+
+	for n := len(tokens); n >= 1; n-- {
+		name, ok := byNorm[normalizeEnvKey(strings.Join(tokens[:n], ""))]
+		if !ok {
+			continue
+		}
+		rest := segments[1:]
+		if n < len(tokens) {
+			rest = append([][]string{tokens[n:]}, rest...)
+		}
+		return name, candidates[name], rest, true
+	}
+	return "", nil, nil, false
+}
+
+func normalizeEnvKey(s string) string {
+	s = strings.ToLower(s)
+	s = strings.ReplaceAll(s, "_", "")
+	return strings.ReplaceAll(s, "-", "")
+}
+
+func orRoot(path string) string {
+	if path == "" {
+		return "config"
+	}
+	return path
+}
+
+// elemType is the type of values inside t when t is a map, nil otherwise.
+func elemType(t reflect.Type) reflect.Type {
+	if t != nil && t.Kind() == reflect.Map {
+		return t.Elem()
+	}
+	return nil
+}
+
+func isScalarList(t reflect.Type) bool {
+	e := t.Elem()
+	for e.Kind() == reflect.Pointer {
+		e = e.Elem()
+	}
+	switch e.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice:
+		return false
+	}
+	return true
+}
+
+// envValueNode turns a variable's value into a node of the shape t expects:
+// comma-separated lists for scalar lists, plain strings for strings, and
+// untagged scalars (resolved like YAML) for everything else.
+func envValueNode(t reflect.Type, value string) *yaml.Node {
+	if t != nil && t.Kind() == reflect.Slice && isScalarList(t) {
+		seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				seq.Content = append(seq.Content, envValueNode(t.Elem(), item))
+			}
+		}
+		return seq
+	}
+	if t != nil && t.Kind() == reflect.String {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+}
+
+// overlayNode returns src merged over dst without modifying dst; untouched
+// subtrees of dst are shared with the result.
+func overlayNode(dst, src *yaml.Node) *yaml.Node {
+	if dst.Kind != yaml.MappingNode || src.Kind != yaml.MappingNode {
+		return src
+	}
+	out := *dst
+	out.Content = append([]*yaml.Node(nil), dst.Content...)
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, val := src.Content[i], src.Content[i+1]
+		found := false
+		for j := 0; j+1 < len(out.Content); j += 2 {
+			if out.Content[j].Value == key.Value {
+				out.Content[j+1] = overlayNode(out.Content[j+1], val)
+				found = true
+				break
+			}
+		}
+		if !found {
+			out.Content = append(out.Content, key, val)
+		}
+	}
+	return &out
+}
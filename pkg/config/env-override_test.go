@@ -0,0 +1,146 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnvOverrides_TypedFields(t *testing.T) {
+	path := writeTempFile(t, "lilium.yaml", `
+server:
+  port: 8000
+  cors:
+    origins: ["https://old.example"]
+  static:
+    - route: /
+      directory: ./public
+    - route: /docs
+      directory: ./docs
+`)
+	t.Setenv("LILIUM_SERVER_PORT", "9000")
+	t.Setenv("LILIUM_SERVER__CORS__ORIGINS", "https://a.example, https://b.example")
+	t.Setenv("LILIUM_SERVER__CORS__ALLOW_CREDENTIALS", "true")
+	t.Setenv("LILIUM_SERVER__STATIC__1__DIRECTORY", "/srv/docs")
+	t.Setenv("LILIUM_RELOAD__INTERVAL", "30s")
+	t.Setenv("LILIUM_LOGGER__LEVELS__HTTP", "warn")
+
+	cfg, err := LoadWithOptions(path, LoadOptions{NoValidate: true})
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	if cfg.Server.Port != 9000 {
+		t.Errorf("expected port 9000, got %d", cfg.Server.Port)
+	}
+	if got := cfg.Server.Cors.Origins; len(got) != 2 || got[1] != "https://b.example" {
+		t.Errorf("expected two origins, got %v", got)
+	}
+	if !cfg.Server.Cors.AllowCredentials {
+		t.Error("expected allowCredentials=true")
+	}
+	if len(cfg.Server.Static) != 2 || cfg.Server.Static[0].Directory != "./public" || cfg.Server.Static[1].Directory != "/srv/docs" {
+		t.Errorf("expected only static[1].directory to change, got %+v", cfg.Server.Static)
+	}
+	if cfg.Reload.Interval != 30*time.Second {
+		t.Errorf("expected reload interval 30s, got %s", cfg.Reload.Interval)
+	}
+	if cfg.Logger.Levels["http"] != "warn" {
+		t.Errorf("expected logger.levels.http=warn, got %v", cfg.Logger.Levels)
+	}
+
+	if pos, _ := cfg.Position("server.port"); pos.File != "$LILIUM_SERVER_PORT" {
+		t.Errorf("expected server.port to be attributed to the env var, got %+v", pos)
+	}
+	if pos, _ := cfg.Position("server.static[0].directory"); pos.File != path {
+		t.Errorf("expected static[0] to keep its file position, got %+v", pos)
+	}
+}
+
+func TestEnvOverrides_Extras(t *testing.T) {
+	path := writeTempFile(t, "lilium.yaml", "auth:\n  provider: google\n  tokenTTL: 3600\n")
+	t.Setenv("LILIUM_AUTH_TOKEN_TTL", "60")
+	t.Setenv("LILIUM_MAILER__HOST", "smtp.example.com")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	auth := cfg.Extras["auth"].(map[string]any)
+	if auth["tokenTTL"] != 60 || auth["provider"] != "google" {
+		t.Errorf("expected auth.tokenTTL overridden in place, got %v", auth)
+	}
+	if _, ok := cfg.Extras["mailer"]; ok {
+		t.Errorf("expected an undeclared mailer section to be ignored, got %v", cfg.Extras["mailer"])
+	}
+	if len(cfg.Warnings) != 1 || !strings.Contains(cfg.Warnings[0], `$LILIUM_MAILER__HOST ignored: config: no config key matches "MAILER"`) {
+		t.Errorf("expected a warning for the ignored variable, got %q", cfg.Warnings)
+	}
+}
+
+func TestEnvOverrides_IgnoresUnknownVariables(t *testing.T) {
+	registerTestAuth(t)
+	path := writeTempFile(t, "lilium.yaml", "server:\n  port: 8000\n")
+	t.Setenv("LILIUM_FOO_BAR", "1")
+	t.Setenv("LILIUM_SERVER__PROT", "9000")
+	t.Setenv("LILIUM_AUTH__TOKEN_TTL", "60")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if len(cfg.Extras) != 1 || cfg.Server.Port != 8000 {
+		t.Errorf("expected only the registered section to be set, got %v, port %d", cfg.Extras, cfg.Server.Port)
+	}
+	if ttl, _ := Extra[int](cfg, "auth.tokenTTL"); ttl != 60 {
+		t.Errorf("expected the registered section to be overridden, got %d", ttl)
+	}
+	want := []string{
+		`$LILIUM_FOO_BAR ignored: config: no config key matches "FOO_BAR"`,
+		`$LILIUM_SERVER__PROT ignored: server: no config key matches "PROT"`,
+	}
+	if strings.Join(cfg.Warnings, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected warnings %q", cfg.Warnings)
+	}
+}
+
+func TestEnvOverrides_Errors(t *testing.T) {
+	path := writeTempFile(t, "lilium.yaml", "server:\n  port: 8000\n")
+
+	cases := map[string]string{
+		"LILIUM_SERVER__PORT":             "$LILIUM_SERVER__PORT: server.port",
+		"LILIUM_SERVER__STATIC__3__ROUTE": "past the end",
+	}
+	for name, want := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(name, "not-a-port")
+			_, err := Load(path)
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Fatalf("expected error containing %q, got %v", want, err)
+			}
+		})
+	}
+}
+
+func TestEnvOverrides_CustomPrefixAndDisable(t *testing.T) {
+	path := writeTempFile(t, "lilium.yaml", "name: file\n")
+	t.Setenv("MYAPP-NAME", "custom")
+	t.Setenv("LILIUM_NAME", "default-prefix")
+
+	cfg, err := LoadWithOptions(path, LoadOptions{EnvPrefix: "MYAPP-", EnvSeparator: "."})
+	if err != nil {
+		t.Fatalf("LoadWithOptions returned error: %v", err)
+	}
+	if cfg.Name != "custom" {
+		t.Errorf("expected the custom prefix to apply, got %s", cfg.Name)
+	}
+
+	cfg, err = LoadWithOptions(path, LoadOptions{NoEnv: true})
+	if err != nil {
+		t.Fatalf("LoadWithOptions returned error: %v", err)
+	}
+	if cfg.Name != "file" {
+		t.Errorf("expected overrides to be disabled, got %s", cfg.Name)
+	}
+}
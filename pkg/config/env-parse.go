@@ -1,41 +1,93 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/spyder01/lilium-go/pkg/utils/env"
 )
 
-func expandEnvFields(v reflect.Value) {
-	if v.Kind() == reflect.Ptr {
+// expandEnvFields expands every string reachable from v: struct fields,
+// slice elements and map values, including values nested inside
+// map[string]any such as Extras. path names the value in errors.
+func expandEnvFields(v reflect.Value, path string, errs *[]error) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
 		if v.IsNil() {
 			return
 		}
-		v = v.Elem()
-	}
-
-	switch v.Kind() {
+		if v.Kind() == reflect.Interface {
+			// Interface contents are not addressable; expand a copy and
+			// store it back.
+			cp := reflect.New(v.Elem().Type()).Elem()
+			cp.Set(v.Elem())
+			expandEnvFields(cp, path, errs)
+			if v.CanSet() {
+				v.Set(cp)
+			}
+			return
+		}
+		expandEnvFields(v.Elem(), path, errs)
 
 	case reflect.Struct:
+		t := v.Type()
 		for i := 0; i < v.NumField(); i++ {
 			field := v.Field(i)
-			if field.CanSet() {
-				expandEnvFields(field)
+			if !field.CanSet() {
+				continue
 			}
+			// Name fields as in YAML; inline maps (Extras) add no segment.
+			name, opts, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+			fieldPath := path
+			switch {
+			case strings.Contains(opts, "inline"):
+			case name == "" || name == "-":
+				fieldPath = joinPath(path, t.Field(i).Name)
+			default:
+				fieldPath = joinPath(path, name)
+			}
+			expandEnvFields(field, fieldPath, errs)
 		}
 
 	case reflect.Slice:
 		for i := 0; i < v.Len(); i++ {
-			expandEnvFields(v.Index(i))
+			expandEnvFields(v.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(key))
+			expandEnvFields(elem, joinPath(path, fmt.Sprint(key.Interface())), errs)
+			v.SetMapIndex(key, elem)
 		}
 
 	case reflect.String:
-		// Apply your function to string fields
-		str := v.String()
-		v.SetString(env.ExpandEnvWithDefault(str))
+		str, _, err := env.Expand(v.String())
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: %w", path, err))
+			return
+		}
+		v.SetString(str)
 	}
 }
 
+// ExpandEnv expands ${...} references in every string of a config that was
+// built in code rather than loaded with Load, which already expands each
+// YAML scalar. It supports the same syntax, and reports every reference that
+// could not be resolved; values that failed are left unchanged.
+func ExpandEnv(cfg *LiliumConfig) error {
+	var errs []error
+	expandEnvFields(reflect.ValueOf(cfg), "", &errs)
+	return errors.Join(errs...)
+}
+
+// ResolveEnv expands ${...} references like ExpandEnv, leaving values that
+// cannot be resolved unchanged.
+//
+// Deprecated: use ExpandEnv, which reports those values.
 func ResolveEnv(cfg *LiliumConfig) {
-	expandEnvFields(reflect.ValueOf(cfg))
+	_ = ExpandEnv(cfg)
 }
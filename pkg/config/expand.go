@@ -0,0 +1,71 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/spyder01/lilium-go/pkg/utils/env"
+	"gopkg.in/yaml.v3"
+)
+
+// expandNodes resolves ${...} references in every scalar value below n.
+// Plain scalars that changed lose their resolved tag so YAML types them
+// again ("${PORT:8080}" becomes an int); quoted scalars stay strings. Nodes
+// listed in skip were expanded already. ENC[...] values are decrypted
+// instead of expanded. Secret values and the text of expanded references
+// are recorded in st.
+func expandNodes(n *yaml.Node, path, file string, skip map[*yaml.Node]string, st *loadState) error {
+	var errs ValidationErrors
+	expandNode(n, path, file, skip, st, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func expandNode(n *yaml.Node, path, file string, skip map[*yaml.Node]string, st *loadState, errs *ValidationErrors) {
+	if _, done := skip[n]; done {
+		return
+	}
+
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			expandNode(n.Content[i+1], joinPath(path, n.Content[i].Value), file, skip, st, errs)
+		}
+	case yaml.SequenceNode:
+		for i, item := range n.Content {
+			expandNode(item, fmt.Sprintf("%s[%d]", path, i), file, skip, st, errs)
+		}
+	case yaml.ScalarNode:
+		var value string
+		var secret bool
+		var err error
+		if IsEncrypted(n.Value) {
+			value, err = st.keys.decrypt(n.Value)
+			secret = true
+		} else {
+			value, secret, err = env.Expand(n.Value)
+		}
+		if err != nil {
+			*errs = append(*errs, &FieldError{
+				Pos:  Position{File: file, Line: n.Line, Column: n.Column},
+				Path: path,
+				Msg:  err.Error(),
+			})
+			return
+		}
+		if value == n.Value {
+			return
+		}
+		if !IsEncrypted(n.Value) {
+			st.exprs[n] = n.Value
+		}
+		n.Value = value
+		if n.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle|yaml.TaggedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+			n.Tag = ""
+		}
+		if secret {
+			st.secrets[n] = true
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spyder01/lilium-go/pkg/utils/env"
+)
+
+// vaultStub mimics a KV secrets API: GET /v1/<path> returns {"data":{<key>:<value>}}.
+func vaultStub(t *testing.T, secrets map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "test-token" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		v, ok := secrets[strings.TrimPrefix(r.URL.Path, "/v1/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{"value": v}})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func vaultResolver(baseURL string) env.Resolver {
+	return env.ResolverFunc(func(ref string) (string, error) {
+		req, _ := http.NewRequest(http.MethodGet, baseURL+"/v1/"+ref, nil)
+		req.Header.Set("X-Vault-Token", "test-token")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", errors.New(resp.Status)
+		}
+		var body struct {
+			Data map[string]string `json:"data"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return "", err
+		}
+		return body.Data["value"], nil
+	})
+}
+
+func TestLoad_SecretResolvers(t *testing.T) {
+	srv := vaultStub(t, map[string]string{"secret/db": "from-vault"})
+	env.RegisterResolver("vault", vaultResolver(srv.URL))
+	defer env.UnregisterResolver("vault")
+
+	secretFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(secretFile, []byte("file-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PORT", "9100")
+
+	path := writeTempFile(t, "lilium.yaml", `
+server:
+  port: ${PORT:8080}
+  admin:
+    token: ${file:`+secretFile+`}
+db:
+  password: ${vault:secret/db}
+  user: ${env:PORT}
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	if cfg.Server.Port != 9100 || cfg.Server.Admin.Token != "file-token" {
+		t.Fatalf("unexpected values: port=%d token=%q", cfg.Server.Port, cfg.Server.Admin.Token)
+	}
+	if db := cfg.Extras["db"].(map[string]any); db["password"] != "from-vault" {
+		t.Fatalf("expected the vault secret, got %v", db)
+	}
+
+	for path, want := range map[string]bool{
+		"server.admin.token": true,
+		"db.password":        true,
+		"server.port":        false,
+		"db.user":            false,
+	} {
+		if got := cfg.IsSecret(path); got != want {
+			t.Errorf("IsSecret(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestLoad_ResolutionErrorsHavePositions(t *testing.T) {
+	path := writeTempFile(t, "lilium.yaml", "server:\n  admin:\n    token: ${file:/does/not/exist}\n")
+
+	_, err := Load(path)
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) || len(verrs) != 1 {
+		t.Fatalf("expected one validation error, got %v", err)
+	}
+	if verrs[0].Path != "server.admin.token" || verrs[0].Pos.Line != 3 || verrs[0].Pos.Column != 12 {
+		t.Fatalf("unexpected error location: %+v", verrs[0])
+	}
+}
+
+func TestEnvOverrides_UseResolvers(t *testing.T) {
+	path := writeTempFile(t, "lilium.yaml", "name: app\n")
+	t.Setenv("LILIUM_SERVER__ADMIN__TOKEN", "${base64:c2VjcmV0}")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.Server.Admin.Token != "secret" || !cfg.IsSecret("server.admin.token") {
+		t.Fatalf("expected a resolved secret from the override, got %q secret=%v", cfg.Server.Admin.Token, cfg.IsSecret("server.admin.token"))
+	}
+}
+
+func TestLoad_RequiredVariable(t *testing.T) {
+	os.Unsetenv("ADMIN_TOKEN")
+	path := writeTempFile(t, "lilium.yaml", "server:\n  admin:\n    token: ${ADMIN_TOKEN:?admin token is required}\n")
+
+	_, err := Load(path)
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) || len(verrs) != 1 {
+		t.Fatalf("expected one validation error, got %v", err)
+	}
+	if !strings.Contains(verrs[0].Msg, "admin token is required") {
+		t.Fatalf("expected the required message, got %v", err)
+	}
+	if verrs[0].Path != "server.admin.token" || verrs[0].Pos.Line != 3 {
+		t.Fatalf("unexpected error location: %+v", verrs[0])
+	}
+}
+
+func TestLoad_ExpansionCannotCorruptDocument(t *testing.T) {
+	// A value with a colon, quotes and a newline stays inside its scalar.
+	t.Setenv("APP_NAME", "evil: \"x\"\nserver: {port: 1}")
+	path := writeTempFile(t, "lilium.yaml", `
+name: ${APP_NAME}
+server:
+  port: ${PORT:-8081}
+  cors:
+    origins: ["$${NOT_EXPANDED}"]
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.Name != "evil: \"x\"\nserver: {port: 1}" {
+		t.Fatalf("expected the raw value as the name, got %q", cfg.Name)
+	}
+	if cfg.Server.Port != 8081 {
+		t.Fatalf("expected port 8081, got %d", cfg.Server.Port)
+	}
+	if cfg.Server.Cors.Origins[0] != "${NOT_EXPANDED}" {
+		t.Fatalf("expected the escaped reference to stay literal, got %v", cfg.Server.Cors.Origins)
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("SITE_DIR", "/srv/site")
+	os.Unsetenv("MISSING")
+
+	cfg := &LiliumConfig{
+		Name:   "${APP:-lilium}",
+		Server: &ServerConfig{Static: []StaticConfig{{Route: "/", Directory: "${SITE_DIR}"}}},
+		Logger: &LogConfig{Levels: map[string]string{"http": "${HTTP_LEVEL:-warn}"}},
+		Extras: map[string]any{
+			"auth": map[string]any{"secret": "${base64:c2VjcmV0}", "scopes": []any{"${SCOPE:-read}"}},
+		},
+	}
+	if err := ExpandEnv(cfg); err != nil {
+		t.Fatalf("ExpandEnv returned error: %v", err)
+	}
+
+	if cfg.Name != "lilium" || cfg.Server.Static[0].Directory != "/srv/site" || cfg.Logger.Levels["http"] != "warn" {
+		t.Fatalf("unexpected expansion: %+v %+v %v", cfg.Name, cfg.Server.Static, cfg.Logger.Levels)
+	}
+	auth := cfg.Extras["auth"].(map[string]any)
+	if auth["secret"] != "secret" || auth["scopes"].([]any)[0] != "read" {
+		t.Fatalf("expected Extras to be expanded, got %v", auth)
+	}
+
+	err := ExpandEnv(&LiliumConfig{Server: &ServerConfig{Admin: &AdminConfig{Token: "${MISSING:?needed}"}}})
+	if err == nil || !strings.Contains(err.Error(), "server.admin.token") {
+		t.Fatalf("expected an error naming server.admin.token, got %v", err)
+	}
+
+	legacy := &LiliumConfig{Name: "${APP:-lilium}"}
+	ResolveEnv(legacy)
+	if legacy.Name != "lilium" {
+		t.Fatalf("expected ResolveEnv to keep expanding, got %q", legacy.Name)
+	}
+}
@@ -0,0 +1,291 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrExtraNotFound is returned by Extra and DecodePath when nothing is set at
+// the path and no defaults were registered for it.
+var ErrExtraNotFound = errors.New("extra config not found")
+
+// extraDefaults holds the defaults passed to RegisterExtra, guarded by
+// schemaMu.
+var extraDefaults = make(map[string]*yaml.Node)
+
+// RegisterExtra declares the type of the Extras section key and its default
+// values, e.g. RegisterExtra("auth", AuthConfig{TokenTTL: 3600}). As with
+// RegisterSchema, Load reports unknown keys and bad types in the section and
+// runs T.Validate when T implements Validator. Values missing from the files
+// are filled in from defaults, both in cfg.Extras and in Extra.
+func RegisterExtra[T any](key string, defaults T) {
+	var n yaml.Node
+	if err := n.Encode(defaults); err != nil {
+		panic(fmt.Sprintf("config: cannot encode defaults for %s: %v", key, err))
+	}
+	RegisterSchema(key, defaults)
+
+	schemaMu.Lock()
+	extraDefaults[key] = &n
+	schemaMu.Unlock()
+}
+
+func lookupExtraDefaults(key string) (*yaml.Node, bool) {
+	schemaMu.RLock()
+	defer schemaMu.RUnlock()
+	n, ok := extraDefaults[key]
+	return n, ok
+}
+
+// Extra decodes the Extras value at path, a dotted path such as "auth" or
+// "auth.google", into a T. Sections registered with RegisterExtra start from
+// their defaults, so keys missing from the files keep their default values.
+//
+// Results are cached per config. A reload produces a new config, so values
+// read through Watcher.Config() always match the rest of that config.
+// Treat the result as read-only.
+func Extra[T any](c *LiliumConfig, path string) (T, error) {
+	var zero T
+	v, err := c.extraValue(path, reflect.TypeOf(&zero).Elem())
+	if err != nil {
+		return zero, err
+	}
+	return v.(T), nil
+}
+
+// GetExtra decodes the Extras value at path into out; see Extra.
+func GetExtra[T any](c *LiliumConfig, path string, out *T) error {
+	v, err := Extra[T](c, path)
+	if err != nil {
+		return err
+	}
+	*out = v
+	return nil
+}
+
+// DecodePath decodes the value at a dotted path such as "server.port" or
+// "auth.tokenTTL" into out, which must be a pointer. Paths outside the
+// built-in sections are read from Extras as with Extra.
+func (c *LiliumConfig) DecodePath(path string, out any) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("config: DecodePath needs a non-nil pointer, got %T", out)
+	}
+	segments := strings.Split(path, ".")
+
+	if !isBuiltinKey(segments[0]) {
+		v, err := c.extraValue(path, rv.Type().Elem())
+		if err != nil {
+			return err
+		}
+		if v != nil {
+			rv.Elem().Set(reflect.ValueOf(v))
+		}
+		return nil
+	}
+
+	var root yaml.Node
+	if err := root.Encode(c); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	n := &root
+	if n.Kind == yaml.DocumentNode {
+		n = n.Content[0]
+	}
+	if n = nodeAt(n, segments); n == nil || n.Tag == "!!null" {
+		return fmt.Errorf("%w: %s", ErrExtraNotFound, path)
+	}
+	if err := n.Decode(out); err != nil {
+		return fmt.Errorf("%s: %s", path, yamlErrorMessage(err))
+	}
+	return nil
+}
+
+// isBuiltinKey reports whether key is a top-level field of LiliumConfig
+// rather than an Extras section.
+func isBuiltinKey(key string) bool {
+	t := reflect.TypeOf(LiliumConfig{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name != "" && name != "-" && name == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *LiliumConfig) extraValue(path string, t reflect.Type) (any, error) {
+	if v, ok := c.typed.load(path, t); ok {
+		return v, nil
+	}
+
+	segments := strings.Split(path, ".")
+	out := reflect.New(t)
+	found := false
+
+	if def, ok := lookupExtraDefaults(segments[0]); ok {
+		if n := nodeAt(def, segments[1:]); n != nil {
+			if err := n.Decode(out.Interface()); err != nil {
+				return nil, fmt.Errorf("%s: defaults: %s", path, yamlErrorMessage(err))
+			}
+			found = true
+		}
+	}
+	if v, ok := valueAt(c.Extras, segments); ok {
+		var n yaml.Node
+		if err := n.Encode(v); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if err := n.Decode(out.Interface()); err != nil {
+			return nil, fmt.Errorf("%s: %s", path, yamlErrorMessage(err))
+		}
+		found = true
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrExtraNotFound, path)
+	}
+
+	v := out.Elem().Interface()
+	c.typed.store(path, t, v)
+	return v, nil
+}
+
+// checkExtras decodes every registered section of the merged config, which
+// also fills the cache, and runs the sections' Validate methods.
+func (c *LiliumConfig) checkExtras() error {
+	var errs ValidationErrors
+	for key, t := range registeredSchemas() {
+		v, err := c.extraValue(key, t)
+		if errors.Is(err, ErrExtraNotFound) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, c.fieldError(key, "%s", strings.TrimPrefix(err.Error(), key+": ")))
+			continue
+		}
+		if val, ok := v.(Validator); ok {
+			if err := val.Validate(); err != nil {
+				errs = append(errs, c.fieldError(key, "%v", err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// applyExtraDefaults fills the registered sections of cfg.Extras with their
+// defaults, keeping every value that was set.
+func applyExtraDefaults(cfg *LiliumConfig) {
+	schemaMu.RLock()
+	defaults := make(map[string]*yaml.Node, len(extraDefaults))
+	for k, n := range extraDefaults {
+		defaults[k] = n
+	}
+	schemaMu.RUnlock()
+
+	if cfg.Extras == nil {
+		cfg.Extras = make(map[string]any)
+	}
+	for key, n := range defaults {
+		var base any
+		if err := n.Decode(&base); err != nil {
+			continue
+		}
+		cfg.Extras[key] = mergeValues(base, cfg.Extras[key])
+	}
+}
+
+// mergeValues returns src merged over dst: maps key by key, anything else
+// replaced when set.
+func mergeValues(dst, src any) any {
+	dm, dok := dst.(map[string]any)
+	sm, sok := src.(map[string]any)
+	if !dok || !sok {
+		if src == nil {
+			return dst
+		}
+		return src
+	}
+	for k, v := range sm {
+		dm[k] = mergeValues(dm[k], v)
+	}
+	return dm
+}
+
+// nodeAt returns the node below n at path, or nil.
+func nodeAt(n *yaml.Node, path []string) *yaml.Node {
+	for _, key := range path {
+		if n.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == key {
+				next = n.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		n = next
+	}
+	return n
+}
+
+// valueAt looks up path in the generic maps of Extras.
+func valueAt(extras map[string]any, path []string) (any, bool) {
+	var cur any = extras
+	for _, key := range path {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// extraCache holds decoded Extras values by path and type. A nil cache
+// stores nothing, for configs built in code.
+type extraCache struct {
+	mu     sync.Mutex
+	values map[extraKey]any
+}
+
+type extraKey struct {
+	path string
+	typ  reflect.Type
+}
+
+func newExtraCache() *extraCache {
+	return &extraCache{values: make(map[extraKey]any)}
+}
+
+func (e *extraCache) load(path string, t reflect.Type) (any, bool) {
+	if e == nil {
+		return nil, false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	v, ok := e.values[extraKey{path, t}]
+	return v, ok
+}
+
+func (e *extraCache) store(path string, t reflect.Type, v any) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	e.values[extraKey{path, t}] = v
+	e.mu.Unlock()
+}
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testOAuthConfig struct {
+	ClientID string   `yaml:"clientID"`
+	Scopes   []string `yaml:"scopes"`
+}
+
+type testAuthConfig struct {
+	Provider string                     `yaml:"provider"`
+	TokenTTL int                        `yaml:"tokenTTL"`
+	Google   testOAuthConfig            `yaml:"google"`
+	Clients  map[string]testOAuthConfig `yaml:"clients"`
+}
+
+func (a testAuthConfig) Validate() error {
+	if a.Provider == "google" && a.Google.ClientID == "" {
+		return fmt.Errorf("google.clientID is required")
+	}
+	return nil
+}
+
+func registerTestAuth(t *testing.T) {
+	t.Helper()
+	RegisterExtra("auth", testAuthConfig{
+		Provider: "local",
+		TokenTTL: 3600,
+		Google:   testOAuthConfig{Scopes: []string{"email"}},
+	})
+	t.Cleanup(func() {
+		schemaMu.Lock()
+		delete(schemas, "auth")
+		delete(extraDefaults, "auth")
+		schemaMu.Unlock()
+	})
+}
+
+func TestExtra_DefaultsAndPaths(t *testing.T) {
+	registerTestAuth(t)
+	cfg, err := Load(writeTempFile(t, "lilium.yaml", `auth:
+  provider: google
+  google:
+    clientID: abc
+plugins:
+  cache:
+    size: 64
+`))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	auth, err := Extra[testAuthConfig](cfg, "auth")
+	if err != nil {
+		t.Fatalf("Extra: %v", err)
+	}
+	if auth.Provider != "google" || auth.TokenTTL != 3600 || auth.Google.ClientID != "abc" {
+		t.Fatalf("unexpected section %+v", auth)
+	}
+	if len(auth.Google.Scopes) != 1 || auth.Google.Scopes[0] != "email" {
+		t.Fatalf("nested defaults lost: %+v", auth.Google)
+	}
+
+	google, err := Extra[testOAuthConfig](cfg, "auth.google")
+	if err != nil || google.ClientID != "abc" || len(google.Scopes) != 1 {
+		t.Fatalf("Extra(auth.google) = %+v, %v", google, err)
+	}
+	size, err := Extra[int](cfg, "plugins.cache.size")
+	if err != nil || size != 64 {
+		t.Fatalf("Extra(plugins.cache.size) = %d, %v", size, err)
+	}
+	if _, err := Extra[int](cfg, "plugins.missing"); !errors.Is(err, ErrExtraNotFound) {
+		t.Fatalf("expected ErrExtraNotFound, got %v", err)
+	}
+	if ttl, _ := valueAt(cfg.Extras, []string{"auth", "tokenTTL"}); ttl != 3600 {
+		t.Fatalf("defaults should show up in Extras, got %v", ttl)
+	}
+
+	// Defaults are copied, never shared between configs.
+	auth.Google.Scopes[0] = "mutated"
+	other, err := Load(writeTempFile(t, "lilium.yaml", "name: other\n"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if a, _ := Extra[testAuthConfig](other, "auth"); a.Provider != "local" || a.Google.Scopes[0] != "email" {
+		t.Fatalf("expected pristine defaults, got %+v", a)
+	}
+}
+
+func TestDecodePath_BuiltinAndExtras(t *testing.T) {
+	registerTestAuth(t)
+	cfg, err := Load(writeTempFile(t, "lilium.yaml", "server:\n  port: 9000\nauth:\n  provider: google\n  google:\n    clientID: abc\n"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	var port uint
+	if err := cfg.DecodePath("server.port", &port); err != nil || port != 9000 {
+		t.Fatalf("DecodePath(server.port) = %d, %v", port, err)
+	}
+	var timeout time.Duration
+	if err := cfg.DecodePath("server.disposeTimeout", &timeout); err != nil || timeout != 5*time.Second {
+		t.Fatalf("DecodePath(server.disposeTimeout) = %v, %v", timeout, err)
+	}
+	var ttl int
+	if err := cfg.DecodePath("auth.tokenTTL", &ttl); err != nil || ttl != 3600 {
+		t.Fatalf("DecodePath(auth.tokenTTL) = %d, %v", ttl, err)
+	}
+	if err := cfg.DecodePath("server.nope", &ttl); !errors.Is(err, ErrExtraNotFound) {
+		t.Fatalf("expected ErrExtraNotFound, got %v", err)
+	}
+	if err := cfg.DecodePath("server.port", &[]string{}); err == nil || !strings.Contains(err.Error(), "server.port") {
+		t.Fatalf("expected a decode error naming the path, got %v", err)
+	}
+}
+
+func TestExtra_ReportsUnknownKeysAndValidatesMergedSection(t *testing.T) {
+	registerTestAuth(t)
+
+	_, err := Load(writeTempFile(t, "lilium.yaml", "auth:\n  tokenTL: 60\n"))
+	if err == nil || !strings.Contains(err.Error(), `auth.tokenTL: unknown key, did you mean "tokenTTL"?`) {
+		t.Fatalf("expected an unknown key error, got %v", err)
+	}
+
+	// The profile alone would fail Validate; merged with the base it passes.
+	path := writeLayers(t, map[string]string{
+		"lilium.yaml":      "auth:\n  google:\n    clientID: abc\n",
+		"lilium.prod.yaml": "auth:\n  provider: google\n",
+	})
+	if _, err := LoadWithOptions(path, LoadOptions{Profile: "prod", NoLocal: true}); err != nil {
+		t.Fatalf("LoadWithOptions: %v", err)
+	}
+	_, err = Load(writeTempFile(t, "lilium.yaml", "auth:\n  provider: google\n"))
+	if err == nil || !strings.Contains(err.Error(), "auth: google.clientID is required") {
+		t.Fatalf("expected the section's Validate error, got %v", err)
+	}
+}
+
+func TestExtra_CachedPerConfig(t *testing.T) {
+	registerTestAuth(t)
+	path := writeTempFile(t, "lilium.yaml", "auth:\n  tokenTTL: 60\n")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if _, ok := cfg.typed.load("auth", reflect.TypeOf(testAuthConfig{})); !ok {
+		t.Fatal("registered sections should be decoded while loading")
+	}
+
+	w, err := NewWatcher(cfg)
+	if err != nil {
+		t.Fatalf("NewWatcher: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("auth:\n  tokenTTL: 120\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	before, _ := Extra[testAuthConfig](cfg, "auth")
+	after, _ := Extra[testAuthConfig](w.Config(), "auth")
+	if before.TokenTTL != 60 || after.TokenTTL != 120 {
+		t.Fatalf("expected 60 before and 120 after the reload, got %d and %d", before.TokenTTL, after.TokenTTL)
+	}
+}
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Flags holds the command-line flags defined by BindFlags.
+type Flags struct {
+	fs      *flag.FlagSet
+	paths   map[string]string // flag name → dotted config path
+	skipped []string          // names fs already defined
+}
+
+// BindFlags defines on fs a flag for every scalar and list-of-scalars field
+// of LiliumConfig and of the Extras sections registered so far, named by
+// path: -server.port, --logger.debugEnabled, --server.cors.origins=a,b.
+// Usage text comes from a field's help tag when it has one, and defaults are
+// those applyDefaults and RegisterExtra fill in. It also defines --config
+// and --profile. Names fs already defines are left to their owner and
+// reported by Skipped; --config and --profile are read from whichever flag
+// holds the name.
+//
+// Flags that were set on the command line override files and environment
+// variables when loading through Flags.Load or Flags.Options.
+func BindFlags(fs *flag.FlagSet) *Flags {
+	f := &Flags{fs: fs, paths: make(map[string]string)}
+	if fs.Lookup("config") == nil {
+		fs.String("config", "lilium.yaml", "`path` of the config file")
+	}
+	if fs.Lookup("profile") == nil {
+		fs.String("profile", "", "config profile, loads lilium.{profile}.yaml (default $"+ProfileEnv+")")
+	}
+
+	defaults := &LiliumConfig{}
+	applyDefaults(defaults)
+	values := flattenConfig(defaults)
+
+	f.bind(reflect.TypeOf(LiliumConfig{}), "", values)
+	schemas := registeredSchemas()
+	keys := make([]string, 0, len(schemas))
+	for key := range schemas {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		f.bind(schemas[key], key, values)
+	}
+	sort.Strings(f.skipped)
+	return f
+}
+
+// ConfigPath returns the value of --config.
+func (f *Flags) ConfigPath() string {
+	return f.fs.Lookup("config").Value.String()
+}
+
+// Skipped returns the config paths that got no flag because fs already
+// defined a flag of that name, sorted.
+func (f *Flags) Skipped() []string {
+	return append([]string(nil), f.skipped...)
+}
+
+// Options returns LoadOptions that use the --profile flag and apply every
+// flag that was set. Call it after parsing.
+func (f *Flags) Options() LoadOptions {
+	return LoadOptions{Profile: f.fs.Lookup("profile").Value.String(), Flags: f}
+}
+
+// Load loads the file named by --config with Options.
+func (f *Flags) Load() (*LiliumConfig, error) {
+	return LoadWithOptions(f.ConfigPath(), f.Options())
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func (f *Flags) bind(t reflect.Type, prefix string, defaults map[string]string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if !field.IsExported() || name == "-" || strings.Contains(opts, "inline") {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		path := joinPath(prefix, name)
+
+		ft := field.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && ft.PkgPath() != "time" && !reflect.PointerTo(ft).Implements(unmarshalerType) {
+			f.bind(ft, path, defaults)
+			continue
+		}
+		if !flagType(ft) {
+			continue
+		}
+
+		if f.fs.Lookup(path) != nil {
+			f.skipped = append(f.skipped, path)
+			continue
+		}
+
+		v := &flagValue{typ: ft}
+		if def := defaults[path]; !isZeroScalar(def) {
+			v.value = def
+		}
+		f.fs.Var(v, path, flagUsage(field, path, ft))
+		f.paths[path] = path
+	}
+}
+
+// flagUsage returns the help tag of field, or a description of path. As in
+// flag.PrintDefaults, a back-quoted word names the value type.
+func flagUsage(field reflect.StructField, path string, t reflect.Type) string {
+	usage := field.Tag.Get("help")
+	switch {
+	case usage != "" && (strings.Contains(usage, "`") || t.Kind() == reflect.Bool):
+		return usage
+	case usage != "":
+		return usage + " (`" + typeName(t) + "`)"
+	case t.Kind() == reflect.Bool:
+		return "sets " + path
+	case t.Kind() == reflect.Slice:
+		return "comma-separated `" + typeName(t) + "` for " + path
+	}
+	return "`" + typeName(t) + "` value for " + path
+}
+
+// flagType reports whether values of t can be given as a single flag.
+func flagType(t reflect.Type) bool {
+	if t.Kind() == reflect.Slice {
+		return isScalarList(t) && flagType(t.Elem())
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func typeName(t reflect.Type) string {
+	switch {
+	case t == durationType:
+		return "duration"
+	case t.Kind() == reflect.Slice:
+		return typeName(t.Elem()) + "s"
+	}
+	return t.Kind().String()
+}
+
+// flagValue keeps the raw text of a flag; it is decoded like an environment
+// override when the config is loaded.
+type flagValue struct {
+	typ   reflect.Type
+	value string
+}
+
+func (v *flagValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.value
+}
+
+func (v *flagValue) Set(s string) error {
+	if !strings.Contains(s, "${") {
+		if err := envValueNode(v.typ, s).Decode(reflect.New(v.typ).Interface()); err != nil {
+			return fmt.Errorf("%s", yamlErrorMessage(err))
+		}
+	}
+	v.value = s
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	return v.typ.Kind() == reflect.Bool
+}
+
+// apply merges every flag that was set into root, after files and
+// environment overrides.
+func (f *Flags) apply(root *yaml.Node, st *loadState) (*yaml.Node, error) {
+	var err error
+	f.fs.Visit(func(fl *flag.Flag) {
+		path, ok := f.paths[fl.Name]
+		if !ok || err != nil {
+			return
+		}
+		var segments [][]string
+		for _, key := range strings.Split(path, ".") {
+			segments = append(segments, []string{key})
+		}
+		root, err = applyOverride(root, segments, fl.Value.String(), "--"+fl.Name, st)
+	})
+	return root, err
+}
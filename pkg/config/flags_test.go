@@ -0,0 +1,133 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+)
+
+func newTestFlags(t *testing.T, args ...string) (*Flags, error) {
+	t.Helper()
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	f := BindFlags(fs)
+	return f, fs.Parse(args)
+}
+
+func TestBindFlags_OverridesFilesAndEnv(t *testing.T) {
+	registerTestAuth(t)
+	path := writeTempFile(t, "lilium.yaml", "server:\n  port: 8000\n  cors:\n    origins: [https://old.example]\nauth:\n  provider: google\n  google:\n    clientID: abc\n")
+	t.Setenv("LILIUM_SERVER_PORT", "9000")
+
+	f, err := newTestFlags(t,
+		"--config", path,
+		"--server.port=9100",
+		"--server.cors.origins", "https://a.example,https://b.example",
+		"--logger.debugEnabled",
+		"--auth.tokenTTL", "60",
+	)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	cfg, err := f.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if cfg.Server.Port != 9100 {
+		t.Errorf("expected the flag to beat the env override, got port %d", cfg.Server.Port)
+	}
+	if got := cfg.Server.Cors.Origins; len(got) != 2 || got[0] != "https://a.example" {
+		t.Errorf("expected origins from the flag, got %v", got)
+	}
+	if !cfg.Logger.DebugEnabled {
+		t.Error("expected a bare bool flag to set logger.debugEnabled")
+	}
+	auth, err := Extra[testAuthConfig](cfg, "auth")
+	if err != nil {
+		t.Fatalf("Extra: %v", err)
+	}
+	if auth.TokenTTL != 60 || auth.Google.ClientID != "abc" {
+		t.Errorf("expected auth.tokenTTL from the flag and the rest from the file, got %+v", auth)
+	}
+	if pos, _ := cfg.Position("server.port"); pos.File != "--server.port" {
+		t.Errorf("expected server.port to be attributed to the flag, got %+v", pos)
+	}
+
+	out, err := Dump(cfg, DumpOptions{})
+	if err != nil {
+		t.Fatalf("Dump: %v", err)
+	}
+	if !strings.Contains(string(out), "# flag --server.port") {
+		t.Errorf("expected the dump to name the flag, got:\n%s", out)
+	}
+}
+
+func TestBindFlags_ProfileAndUnsetFlags(t *testing.T) {
+	path := writeLayers(t, map[string]string{
+		"lilium.yaml":      "name: base\nserver:\n  port: 8000\n",
+		"lilium.prod.yaml": "name: prod\n",
+	})
+	f, err := newTestFlags(t, "-config", path, "-profile", "prod")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	cfg, err := f.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Name != "prod" || cfg.Profile != "prod" {
+		t.Errorf("expected the prod profile, got name=%q profile=%q", cfg.Name, cfg.Profile)
+	}
+	if cfg.Server.Port != 8000 {
+		t.Errorf("expected unset flags to leave the file alone, got port %d", cfg.Server.Port)
+	}
+}
+
+func TestBindFlags_InvalidValue(t *testing.T) {
+	_, err := newTestFlags(t, "--server.port", "eighty")
+	if err == nil || !strings.Contains(err.Error(), "server.port") {
+		t.Fatalf("expected an invalid value error for server.port, got %v", err)
+	}
+}
+
+func TestBindFlags_Usage(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	BindFlags(fs)
+	var buf bytes.Buffer
+	fs.SetOutput(&buf)
+	fs.PrintDefaults()
+	usage := buf.String()
+
+	for _, want := range []string{"-server.port uint", "(default 8080)", "-server.cors.origins strings", "-reload.interval duration", "-config path", "HTTP port to listen on"} {
+		if !strings.Contains(usage, want) {
+			t.Errorf("expected usage to contain %q, got:\n%s", want, usage)
+		}
+	}
+	if strings.Contains(usage, "-server.static") {
+		t.Errorf("expected lists of structs to have no flag, got:\n%s", usage)
+	}
+}
+
+func TestBindFlags_SkipsDefinedNames(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	config := fs.String("config", "app.yaml", "app config")
+	fs.Bool("server.port", false, "owned by the caller")
+
+	f := BindFlags(fs)
+	if err := fs.Parse([]string{"--config", "other.yaml", "--logger.level", "warn"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got := f.Skipped(); len(got) != 1 || got[0] != "server.port" {
+		t.Errorf("expected server.port to be skipped, got %v", got)
+	}
+	if *config != "other.yaml" || f.ConfigPath() != "other.yaml" {
+		t.Errorf("expected --config to stay the caller's flag, got %q and %q", *config, f.ConfigPath())
+	}
+	if f.Options().Profile != "" {
+		t.Errorf("expected an empty profile, got %q", f.Options().Profile)
+	}
+}
@@ -0,0 +1,275 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Format parses one config document into a YAML node tree, which the loader
+// expands, validates and merges the same way whatever the source format.
+// Line and Column should be set on nodes when the format can track them.
+type Format interface {
+	Parse(data []byte) (*yaml.Node, error)
+}
+
+var (
+	YAML Format = yamlFormat{}
+	JSON Format = jsonFormat{}
+	TOML Format = tomlFormat{}
+)
+
+var (
+	formatMu sync.RWMutex
+	formats  = map[string]Format{
+		".yaml": YAML,
+		".yml":  YAML,
+		".json": JSON,
+		".toml": TOML,
+	}
+)
+
+// RegisterFormat makes Load pick f for files ending in ext, e.g. ".hcl".
+func RegisterFormat(ext string, f Format) {
+	formatMu.Lock()
+	formats[strings.ToLower(ext)] = f
+	formatMu.Unlock()
+}
+
+// FormatFor returns the format registered for the extension of path, and
+// YAML for unknown extensions.
+func FormatFor(path string) Format {
+	formatMu.RLock()
+	defer formatMu.RUnlock()
+	if f, ok := formats[strings.ToLower(filepath.Ext(path))]; ok {
+		return f
+	}
+	return YAML
+}
+
+// parseDocument runs f and reports a nil node for an empty document.
+func parseDocument(f Format, data []byte) (*yaml.Node, error) {
+	root, err := f.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if root != nil && root.Kind == yaml.DocumentNode {
+		if len(root.Content) == 0 {
+			return nil, nil
+		}
+		root = root.Content[0]
+	}
+	return root, nil
+}
+
+type yamlFormat struct{}
+
+func (yamlFormat) Parse(data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	return &doc, nil
+}
+
+type jsonFormat struct{}
+
+// Parse reads JSON token by token so every node keeps its line and column.
+func (jsonFormat) Parse(data []byte) (*yaml.Node, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	p := &jsonParser{data: data, dec: json.NewDecoder(bytes.NewReader(data))}
+	p.dec.UseNumber()
+	root, err := p.value()
+	if err == nil {
+		if _, err = p.dec.Token(); err == io.EOF {
+			return root, nil
+		} else if err == nil {
+			err = errors.New("unexpected data after the top-level value")
+		}
+	}
+	offset := int(p.dec.InputOffset())
+	var syntax *json.SyntaxError
+	if errors.As(err, &syntax) {
+		offset = int(syntax.Offset)
+	}
+	line, col := p.position(offset)
+	return nil, fmt.Errorf("failed to parse JSON: line %d, column %d: %w", line, col, err)
+}
+
+type jsonParser struct {
+	data []byte
+	dec  *json.Decoder
+}
+
+// position converts a byte offset into a 1-based line and column.
+func (p *jsonParser) position(offset int) (int, int) {
+	offset = min(offset, len(p.data))
+	line := 1 + bytes.Count(p.data[:offset], []byte("\n"))
+	col := offset - bytes.LastIndexByte(p.data[:offset], '\n')
+	return line, col
+}
+
+// next reads a token and the position where it starts.
+func (p *jsonParser) next() (json.Token, int, int, error) {
+	offset := int(p.dec.InputOffset())
+	for offset < len(p.data) && strings.IndexByte(" \t\r\n:,", p.data[offset]) >= 0 {
+		offset++
+	}
+	tok, err := p.dec.Token()
+	line, col := p.position(offset)
+	return tok, line, col, err
+}
+
+func (p *jsonParser) value() (*yaml.Node, error) {
+	tok, line, col, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	return p.node(tok, line, col)
+}
+
+func (p *jsonParser) node(tok json.Token, line, col int) (*yaml.Node, error) {
+	n := &yaml.Node{Line: line, Column: col}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			n.Kind, n.Tag = yaml.MappingNode, "!!map"
+			for p.dec.More() {
+				key, kl, kc, err := p.next()
+				if err != nil {
+					return nil, err
+				}
+				keyNode, err := p.node(key, kl, kc)
+				if err != nil {
+					return nil, err
+				}
+				val, err := p.value()
+				if err != nil {
+					return nil, err
+				}
+				n.Content = append(n.Content, keyNode, val)
+			}
+		case '[':
+			n.Kind, n.Tag = yaml.SequenceNode, "!!seq"
+			for p.dec.More() {
+				val, err := p.value()
+				if err != nil {
+					return nil, err
+				}
+				n.Content = append(n.Content, val)
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q", t)
+		}
+		if _, err := p.dec.Token(); err != nil { // closing delimiter
+			return nil, err
+		}
+	case string:
+		setStringScalar(n, t)
+	case json.Number:
+		n.Kind, n.Value, n.Tag = yaml.ScalarNode, t.String(), "!!int"
+		if strings.ContainsAny(n.Value, ".eE") {
+			n.Tag = "!!float"
+		}
+	case bool:
+		n.Kind, n.Value, n.Tag = yaml.ScalarNode, strconv.FormatBool(t), "!!bool"
+	case nil:
+		n.Kind, n.Value, n.Tag = yaml.ScalarNode, "null", "!!null"
+	}
+	return n, nil
+}
+
+// setStringScalar makes n a string. Strings holding ${...} references are
+// marked plain, like unquoted YAML, so expanded values are typed again and
+// "port": "${PORT:8080}" works as it does in YAML.
+func setStringScalar(n *yaml.Node, s string) {
+	n.Kind, n.Value, n.Tag = yaml.ScalarNode, s, "!!str"
+	if !strings.Contains(s, "${") {
+		n.Style = yaml.DoubleQuotedStyle
+	}
+}
+
+type tomlFormat struct{}
+
+// Parse decodes TOML into generic values and converts them to nodes. TOML
+// does not report positions, so errors name the file and path only; keys
+// are sorted.
+func (tomlFormat) Parse(data []byte) (*yaml.Node, error) {
+	var doc map[string]any
+	if _, err := toml.Decode(string(data), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse TOML: %w", err)
+	}
+	if len(doc) == 0 {
+		return nil, nil
+	}
+	return tomlNode(doc)
+}
+
+func tomlNode(v any) (*yaml.Node, error) {
+	n := &yaml.Node{Kind: yaml.ScalarNode}
+	switch t := v.(type) {
+	case map[string]any:
+		n.Kind, n.Tag = yaml.MappingNode, "!!map"
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			val, err := tomlNode(t[k])
+			if err != nil {
+				return nil, err
+			}
+			key := &yaml.Node{}
+			setStringScalar(key, k)
+			n.Content = append(n.Content, key, val)
+		}
+	case []map[string]any:
+		n.Kind, n.Tag = yaml.SequenceNode, "!!seq"
+		for _, item := range t {
+			val, err := tomlNode(item)
+			if err != nil {
+				return nil, err
+			}
+			n.Content = append(n.Content, val)
+		}
+	case []any:
+		n.Kind, n.Tag = yaml.SequenceNode, "!!seq"
+		for _, item := range t {
+			val, err := tomlNode(item)
+			if err != nil {
+				return nil, err
+			}
+			n.Content = append(n.Content, val)
+		}
+	case string:
+		setStringScalar(n, t)
+	case int64:
+		n.Value, n.Tag = strconv.FormatInt(t, 10), "!!int"
+	case float64:
+		n.Value, n.Tag = strconv.FormatFloat(t, 'g', -1, 64), "!!float"
+	case bool:
+		n.Value, n.Tag = strconv.FormatBool(t), "!!bool"
+	case time.Time:
+		n.Value, n.Tag = t.Format(time.RFC3339Nano), "!!timestamp"
+	case fmt.Stringer: // local dates and times
+		n.Value, n.Tag = t.String(), "!!str"
+	default:
+		return nil, fmt.Errorf("unsupported TOML value %T", v)
+	}
+	return n, nil
+}
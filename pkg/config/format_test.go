@@ -0,0 +1,143 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var formatDocs = map[string]string{
+	"lilium.yaml": `name: demo
+server:
+  port: ${FORMAT_TEST_PORT:8080}
+  cors:
+    origins: [https://a.example, https://b.example]
+reload:
+  interval: 5s
+db:
+  host: localhost
+  pool: 4
+`,
+	"lilium.json": `{
+  "name": "demo",
+  "server": {
+    "port": "${FORMAT_TEST_PORT:8080}",
+    "cors": {"origins": ["https://a.example", "https://b.example"]}
+  },
+  "reload": {"interval": "5s"},
+  "db": {"host": "localhost", "pool": 4}
+}
+`,
+	"lilium.toml": `name = "demo"
+
+[server]
+port = "${FORMAT_TEST_PORT:8080}"
+
+[server.cors]
+origins = ["https://a.example", "https://b.example"]
+
+[reload]
+interval = "5s"
+
+[db]
+host = "localhost"
+pool = 4
+`,
+}
+
+func TestLoad_FormatsBehaveAlike(t *testing.T) {
+	t.Setenv("FORMAT_TEST_PORT", "9090")
+
+	for name, content := range formatDocs {
+		cfg, err := LoadWithOptions(writeTempFile(t, name, content), LoadOptions{NoLocal: true, NoEnv: true})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if cfg.Name != "demo" || cfg.Server.Port != 9090 || cfg.Reload.Interval.String() != "5s" {
+			t.Errorf("%s: unexpected values %q %d %v", name, cfg.Name, cfg.Server.Port, cfg.Reload.Interval)
+		}
+		if !reflect.DeepEqual(cfg.Server.Cors.Origins, []string{"https://a.example", "https://b.example"}) {
+			t.Errorf("%s: unexpected origins %v", name, cfg.Server.Cors.Origins)
+		}
+		if cfg.Server.Cors.MaxAge != 600 {
+			t.Errorf("%s: defaults not applied", name)
+		}
+		db, _ := cfg.Extras["db"].(map[string]any)
+		if db["host"] != "localhost" || db["pool"] != 4 {
+			t.Errorf("%s: unexpected extras %v", name, cfg.Extras)
+		}
+	}
+}
+
+func TestLoad_JSONErrorsHavePositions(t *testing.T) {
+	path := writeTempFile(t, "lilium.json", `{
+  "server": {
+    "portt": 8080
+  }
+}`)
+	_, err := LoadWithOptions(path, LoadOptions{NoLocal: true, NoEnv: true})
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+	if verrs[0].Pos.Line != 3 || verrs[0].Pos.Column != 5 || !strings.Contains(verrs[0].Msg, `did you mean "port"?`) {
+		t.Fatalf("unexpected error %+v", verrs[0])
+	}
+
+	_, err = LoadWithOptions(writeTempFile(t, "lilium.json", "{\n  \"name\": \n}"), LoadOptions{NoLocal: true})
+	if err == nil || !strings.Contains(err.Error(), "failed to parse JSON: line 3") {
+		t.Fatalf("expected a JSON syntax error with a line, got %v", err)
+	}
+
+	_, err = LoadWithOptions(writeTempFile(t, "lilium.toml", "[server]\nport = \"eighty\"\n"), LoadOptions{NoLocal: true, NoEnv: true})
+	if err == nil || !strings.Contains(err.Error(), "lilium.toml: server.port:") {
+		t.Fatalf("expected a TOML error naming the file and path, got %v", err)
+	}
+}
+
+func TestLoadFS_Layers(t *testing.T) {
+	fsys := fstest.MapFS{
+		"conf/lilium.json":      {Data: []byte(`{"name": "base", "server": {"port": 8000}}`)},
+		"conf/lilium.prod.json": {Data: []byte(`{"server": {"port": 9000}}`)},
+	}
+	cfg, err := LoadFS(fsys, "conf/lilium.json", LoadOptions{Profile: "prod", NoEnv: true})
+	if err != nil {
+		t.Fatalf("LoadFS: %v", err)
+	}
+	if cfg.Name != "base" || cfg.Server.Port != 9000 || len(cfg.Layers) != 2 {
+		t.Fatalf("unexpected config %q %d %v", cfg.Name, cfg.Server.Port, cfg.Layers)
+	}
+	if _, err := NewWatcher(cfg); err == nil {
+		t.Fatal("expected configs from an fs.FS to be unwatchable")
+	}
+}
+
+func TestLoadReader(t *testing.T) {
+	t.Setenv("FORMAT_TEST_PORT", "7070")
+	cfg, err := LoadReader(strings.NewReader(formatDocs["lilium.toml"]), LoadOptions{Format: TOML, NoEnv: true})
+	if err != nil {
+		t.Fatalf("LoadReader: %v", err)
+	}
+	if cfg.Server.Port != 7070 || cfg.Logger.Prefix == "" {
+		t.Fatalf("unexpected config: port %d prefix %q", cfg.Server.Port, cfg.Logger.Prefix)
+	}
+
+	// An explicit format wins over the extension.
+	path := writeTempFile(t, "lilium.conf", `{"name": "from-json"}`)
+	cfg, err = LoadWithOptions(path, LoadOptions{Format: JSON, NoLocal: true, NoEnv: true})
+	if err != nil || cfg.Name != "from-json" {
+		t.Fatalf("LoadWithOptions = %v, %v", cfg, err)
+	}
+}
+
+func TestLoadEnv_JSON(t *testing.T) {
+	env, err := LoadEnv(writeTempFile(t, "env.json", `{"env": {"enableFile": true, "filePath": ".env.local"}}`))
+	if err != nil {
+		t.Fatalf("LoadEnv: %v", err)
+	}
+	if !env.EnableFile || env.FilePath != ".env.local" {
+		t.Fatalf("unexpected env config %+v", env)
+	}
+}
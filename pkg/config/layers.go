@@ -0,0 +1,370 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProfileEnv names the environment variable that selects the config profile
+// when LoadOptions.Profile is empty.
+const ProfileEnv = "LILIUM_PROFILE"
+
+// ListPolicy decides how a list in a later layer combines with the same list
+// in an earlier one.
+type ListPolicy int
+
+const (
+	ListReplace ListPolicy = iota // later layers replace the list (default)
+	ListAppend                    // later layers append to the list
+)
+
+type LoadOptions struct {
+	// Profile selects lilium.{profile}.yaml. Empty falls back to $LILIUM_PROFILE.
+	Profile string
+
+	// NoLocal skips lilium.local.yaml.
+	NoLocal bool
+
+	// EnvPrefix and EnvSeparator control automatic overrides such as
+	// LILIUM_SERVER__CORS__ORIGINS=a,b. Empty values use DefaultEnvPrefix and
+	// DefaultEnvSeparator; NoEnv turns overrides off.
+	EnvPrefix    string
+	EnvSeparator string
+	NoEnv        bool
+
+	// Key decrypts ENC[...] values. Empty falls back to $LILIUM_CONFIG_KEY
+	// and $LILIUM_CONFIG_KEY_FILE, read only when an encrypted value is found.
+	Key []byte
+
+	// Format parses every layer. Nil picks one by file extension (see
+	// FormatFor). FS, when set, is read instead of the OS file system.
+	Format Format
+	FS     fs.FS
+
+	// Flags applies the command-line flags set through BindFlags, over
+	// files and environment overrides.
+	Flags *Flags
+
+	// Lists is the default policy for lists; ListPolicies overrides it per
+	// dotted path, e.g. {"server.static": ListAppend}.
+	Lists        ListPolicy
+	ListPolicies map[string]ListPolicy
+
+	// NoValidate skips Validate, e.g. to inspect a config whose static
+	// directories exist only where it is deployed.
+	NoValidate bool
+}
+
+func (o *LoadOptions) format(path string) Format {
+	if o.Format != nil {
+		return o.Format
+	}
+	return FormatFor(path)
+}
+
+func (o *LoadOptions) listPolicy(path string) ListPolicy {
+	if p, ok := o.ListPolicies[path]; ok {
+		return p
+	}
+	return o.Lists
+}
+
+// LayerPaths returns the files read for path, in merge order: the base file,
+// the profile file (if profile is set) and the local file. For
+// "conf/lilium.yaml" and profile "prod" these are conf/lilium.yaml,
+// conf/lilium.prod.yaml and conf/lilium.local.yaml.
+func LayerPaths(path, profile string, noLocal bool) []string {
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(path, ext)
+
+	paths := []string{path}
+	if profile != "" {
+		paths = append(paths, stem+"."+profile+ext)
+	}
+	if !noLocal {
+		paths = append(paths, stem+".local"+ext)
+	}
+	return paths
+}
+
+// ProfileFromArgs returns the value of a --profile (or -profile) flag in
+// args, or "" when there is none. Other arguments are ignored, so it can run
+// before the application parses its own flags.
+func ProfileFromArgs(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name := strings.TrimLeft(arg, "-")
+		if name == arg {
+			continue
+		}
+		if v, ok := strings.CutPrefix(name, "profile="); ok {
+			return v
+		}
+		if name == "profile" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// LoadWithOptions reads the base file at path and merges the profile and
+// local layers on top of it, followed by environment overrides. Only the base
+// file is required. Maps (including Extras) are merged key by key, lists
+// follow opts, and scalars from later layers win. The result is checked with
+// Validate unless opts.NoValidate is set.
+func LoadWithOptions(path string, opts LoadOptions) (*LiliumConfig, error) {
+	profile := opts.Profile
+	if profile == "" {
+		profile = os.Getenv(ProfileEnv)
+	}
+
+	var root *yaml.Node
+	var loaded []string
+	st := newLoadState(opts.Key)
+	for i, p := range LayerPaths(path, profile, opts.NoLocal) {
+		data, err := readConfigFile(opts.FS, p)
+		if err != nil {
+			if i > 0 && errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		node, err := readLayer(p, data, opts.format(p), st)
+		if err != nil {
+			return nil, err
+		}
+		loaded = append(loaded, p)
+		if node == nil {
+			continue
+		}
+		tagNodes(node, p, st.files)
+		if root == nil {
+			root = node
+		} else {
+			root = mergeNodes(root, node, "", &opts)
+		}
+	}
+
+	cfg, err := build(root, st, opts)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Profile = profile
+	cfg.Layers = loaded
+	cfg.path = path
+	cfg.options.Profile = profile
+	return cfg, nil
+}
+
+// LoadFS works like LoadWithOptions with the layers read from fsys, e.g. an
+// embed.FS. Such configs cannot be watched for changes.
+func LoadFS(fsys fs.FS, path string, opts LoadOptions) (*LiliumConfig, error) {
+	opts.FS = fsys
+	return LoadWithOptions(path, opts)
+}
+
+// LoadReader reads a single document from r in opts.Format (YAML when nil)
+// and processes it like a file: env expansion and overrides, defaults and
+// validation. There are no profile or local layers.
+func LoadReader(r io.Reader, opts LoadOptions) (*LiliumConfig, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	st := newLoadState(opts.Key)
+	root, err := readLayer(readerSource, data, opts.format(""), st)
+	if err != nil {
+		return nil, err
+	}
+	if root != nil {
+		tagNodes(root, readerSource, st.files)
+	}
+	return build(root, st, opts)
+}
+
+// readerSource names documents read by LoadReader in errors and dumps.
+const readerSource = "<reader>"
+
+// build applies environment and flag overrides to the merged tree and decodes it
+// into a config with defaults applied.
+func build(root *yaml.Node, st *loadState, opts LoadOptions) (*LiliumConfig, error) {
+	if !opts.NoEnv {
+		var err error
+		if root, err = applyEnvOverrides(root, &opts, st); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Flags != nil {
+		var err error
+		if root, err = opts.Flags.apply(root, st); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg := &LiliumConfig{
+		positions: make(map[string]Position),
+		secrets:   make(map[string]bool),
+		exprs:     make(map[string]string),
+		typed:     newExtraCache(),
+	}
+	if root != nil {
+		if err := root.Decode(cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+		cfg.Extras = extractUnknownFields(root, cfg)
+		recordPositions(root, "", st, cfg)
+	} else {
+		cfg.Extras = make(map[string]any)
+	}
+	cfg.options = opts
+	cfg.Warnings = st.warnings
+
+	before := flattenConfig(cfg)
+	applyDefaults(cfg)
+	redactSecrets(cfg, st.secrets)
+	cfg.loaded = flattenConfig(cfg)
+	cfg.defaults = make(map[string]bool)
+	for path, v := range cfg.loaded {
+		// Sections created by defaults count only for the values they set.
+		if old, ok := before[path]; ok && old != v || !ok && !isZeroScalar(v) {
+			cfg.defaults[path] = true
+		}
+	}
+
+	if err := cfg.checkExtras(); err != nil {
+		return nil, err
+	}
+	if !opts.NoValidate {
+		if err := cfg.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid config:\n%w", err)
+		}
+	}
+	return cfg, nil
+}
+
+// redactSecrets adds the values of secret nodes to the logger's redaction
+// list so they are masked wherever they show up in logs. Other redaction
+// settings are left as configured.
+func redactSecrets(cfg *LiliumConfig, secrets map[*yaml.Node]bool) {
+	seen := make(map[string]bool)
+	var values []string
+	for n := range secrets {
+		if n.Value != "" && !seen[n.Value] {
+			seen[n.Value] = true
+			values = append(values, n.Value)
+		}
+	}
+	if len(values) == 0 {
+		return
+	}
+	// Longest first, so a secret containing another is masked whole.
+	sort.Slice(values, func(i, j int) bool {
+		if len(values[i]) != len(values[j]) {
+			return len(values[i]) > len(values[j])
+		}
+		return values[i] < values[j]
+	})
+
+	if cfg.Logger.Redact == nil {
+		cfg.Logger.Redact = &RedactConfig{}
+	}
+	cfg.Logger.Redact.Values = append(cfg.Logger.Redact.Values, values...)
+}
+
+// loadState collects what is learned about nodes while layers are read:
+// the file each came from, which hold secrets, the ${...} text each was
+// expanded from, and the key for encrypted values.
+type loadState struct {
+	files    map[*yaml.Node]string
+	secrets  map[*yaml.Node]bool
+	exprs    map[*yaml.Node]string
+	keys     *keyring
+	warnings []string
+}
+
+func newLoadState(key []byte) *loadState {
+	return &loadState{
+		files:   make(map[*yaml.Node]string),
+		secrets: make(map[*yaml.Node]bool),
+		exprs:   make(map[*yaml.Node]string),
+		keys:    newKeyring(key),
+	}
+}
+
+// readConfigFile reads path from fsys, or from the OS when fsys is nil.
+func readConfigFile(fsys fs.FS, path string) ([]byte, error) {
+	if fsys == nil {
+		return os.ReadFile(path)
+	}
+	return fs.ReadFile(fsys, filepath.ToSlash(path))
+}
+
+// readLayer parses one document, resolves its ${...} references and ENC[...]
+// values, and checks it against LiliumConfig and the registered schemas on
+// its own, so a bad value is reported against the file it came from. It
+// returns nil for an empty document.
+func readLayer(path string, data []byte, format Format, st *loadState) (*yaml.Node, error) {
+	root, err := parseDocument(format, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if root == nil {
+		return nil, nil
+	}
+
+	if err := expandNodes(root, "", path, nil, st); err != nil {
+		return nil, err
+	}
+	if err := checkLayer(root, path, st.secrets); err != nil {
+		return nil, err
+	}
+	if err := root.Decode(&LiliumConfig{}); err != nil {
+		return nil, fmt.Errorf("%s: failed to parse YAML: %w", path, err)
+	}
+	return root, nil
+}
+
+// mergeNodes merges src into dst and returns the result. path is the dotted
+// location of dst, used to look up list policies.
+func mergeNodes(dst, src *yaml.Node, path string, opts *LoadOptions) *yaml.Node {
+	switch {
+	case dst.Kind == yaml.MappingNode && src.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			key, val := src.Content[i], src.Content[i+1]
+			childPath := key.Value
+			if path != "" {
+				childPath = path + "." + key.Value
+			}
+
+			found := false
+			for j := 0; j+1 < len(dst.Content); j += 2 {
+				if dst.Content[j].Value == key.Value {
+					dst.Content[j+1] = mergeNodes(dst.Content[j+1], val, childPath, opts)
+					found = true
+					break
+				}
+			}
+			if !found {
+				dst.Content = append(dst.Content, key, val)
+			}
+		}
+		return dst
+
+	case dst.Kind == yaml.SequenceNode && src.Kind == yaml.SequenceNode && opts.listPolicy(path) == ListAppend:
+		dst.Content = append(dst.Content, src.Content...)
+		return dst
+
+	default:
+		return src
+	}
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeLayers(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	return filepath.Join(dir, "lilium.yaml")
+}
+
+func TestLoadWithOptions_MergesLayers(t *testing.T) {
+	path := writeLayers(t, map[string]string{
+		"lilium.yaml": `
+name: base
+server:
+  port: 8000
+  static:
+    - route: /
+      directory: ./public
+auth:
+  provider: google
+  tokenTTL: 3600
+`,
+		"lilium.prod.yaml": `
+server:
+  port: 9000
+auth:
+  tokenTTL: 60
+`,
+		"lilium.local.yaml": `
+name: local
+`,
+	})
+
+	cfg, err := LoadWithOptions(path, LoadOptions{Profile: "prod", NoValidate: true})
+	if err != nil {
+		t.Fatalf("LoadWithOptions returned error: %v", err)
+	}
+
+	if cfg.Name != "local" || cfg.Server.Port != 9000 {
+		t.Fatalf("expected name=local port=9000, got name=%s port=%d", cfg.Name, cfg.Server.Port)
+	}
+	if len(cfg.Server.Static) != 1 {
+		t.Fatalf("expected static dirs from the base layer to survive, got %+v", cfg.Server.Static)
+	}
+
+	var auth struct {
+		Provider string `yaml:"provider"`
+		TokenTTL int    `yaml:"tokenTTL"`
+	}
+	if err := GetExtra(cfg, "auth", &auth); err != nil {
+		t.Fatalf("GetExtra returned error: %v", err)
+	}
+	if auth.Provider != "google" || auth.TokenTTL != 60 {
+		t.Fatalf("expected extras to be deep-merged, got %+v", auth)
+	}
+
+	if cfg.Profile != "prod" || len(cfg.Layers) != 3 {
+		t.Fatalf("expected profile prod and 3 layers, got %q %v", cfg.Profile, cfg.Layers)
+	}
+}
+
+func TestLoadWithOptions_ProfileFromEnv(t *testing.T) {
+	path := writeLayers(t, map[string]string{
+		"lilium.yaml":         "name: base\n",
+		"lilium.staging.yaml": "name: staging\n",
+	})
+	t.Setenv(ProfileEnv, "staging")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	if cfg.Name != "staging" {
+		t.Fatalf("expected the staging layer to apply, got %s", cfg.Name)
+	}
+}
+
+func TestLoadWithOptions_ListPolicies(t *testing.T) {
+	files := map[string]string{
+		"lilium.yaml": `
+server:
+  static:
+    - route: /
+      directory: ./public
+  cors:
+    origins: ["https://a.example"]
+`,
+		"lilium.local.yaml": `
+server:
+  static:
+    - route: /docs
+      directory: ./docs
+  cors:
+    origins: ["https://b.example"]
+`,
+	}
+
+	cfg, err := LoadWithOptions(writeLayers(t, files), LoadOptions{
+		ListPolicies: map[string]ListPolicy{"server.static": ListAppend},
+		NoValidate:   true,
+	})
+	if err != nil {
+		t.Fatalf("LoadWithOptions returned error: %v", err)
+	}
+	if len(cfg.Server.Static) != 2 {
+		t.Fatalf("expected server.static to be appended, got %+v", cfg.Server.Static)
+	}
+	if len(cfg.Server.Cors.Origins) != 1 || cfg.Server.Cors.Origins[0] != "https://b.example" {
+		t.Fatalf("expected cors origins to be replaced, got %v", cfg.Server.Cors.Origins)
+	}
+}
+
+func TestLoadWithOptions_ErrorNamesLayer(t *testing.T) {
+	path := writeLayers(t, map[string]string{
+		"lilium.yaml":       "server:\n  port: 8080\n",
+		"lilium.local.yaml": "server:\n  port: not-a-number\n",
+	})
+
+	_, err := Load(path)
+	if err == nil {
+		t.Fatal("expected an error for a bad port")
+	}
+	if !strings.Contains(err.Error(), "lilium.local.yaml") {
+		t.Fatalf("expected the error to name lilium.local.yaml, got %v", err)
+	}
+}
+
+func TestProfileFromArgs(t *testing.T) {
+	cases := map[string][]string{
+		"prod":    {"-v", "--profile=prod"},
+		"staging": {"-profile", "staging", "serve"},
+		"":        {"serve", "--", "--profile=x"},
+	}
+	for want, args := range cases {
+		if got := ProfileFromArgs(args); got != want {
+			t.Errorf("ProfileFromArgs(%v) = %q, want %q", args, got, want)
+		}
+	}
+}
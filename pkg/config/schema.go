@@ -0,0 +1,387 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Position locates a value in a config file.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	if p.Line == 0 {
+		return p.File
+	}
+	if p.File == "" {
+		return fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// FieldError is a problem with a single config value.
+type FieldError struct {
+	Pos  Position // zero when the value has no source, e.g. a default
+	Path string   // dotted path, e.g. "server.static[1].directory"
+	Msg  string
+}
+
+func (e *FieldError) Error() string {
+	switch {
+	case e.Pos.Line == 0 && e.Pos.File == "":
+		return fmt.Sprintf("%s: %s", e.Path, e.Msg)
+	case e.Pos.Line == 0:
+		// environment overrides have a source but no line
+		return fmt.Sprintf("%s: %s: %s", e.Pos.File, e.Path, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s: %s", e.Pos, e.Path, e.Msg)
+}
+
+// ValidationErrors collects every FieldError found in one pass.
+type ValidationErrors []*FieldError
+
+func (es ValidationErrors) Error() string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Validator is implemented by schemas that check their own values after
+// decoding.
+type Validator interface {
+	Validate() error
+}
+
+var (
+	schemaMu sync.RWMutex
+	schemas  = make(map[string]reflect.Type)
+)
+
+// RegisterSchema declares the shape of the Extras section key. schema is a
+// struct value or pointer, e.g. AuthConfig{}. Registered sections get the
+// same strict checking as the built-in ones, and schema.Validate runs when
+// the type implements Validator.
+func RegisterSchema(key string, schema any) {
+	t := reflect.TypeOf(schema)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		panic("config: RegisterSchema needs a non-nil schema for " + key)
+	}
+
+	schemaMu.Lock()
+	schemas[key] = t
+	schemaMu.Unlock()
+}
+
+func lookupSchema(key string) (reflect.Type, bool) {
+	schemaMu.RLock()
+	defer schemaMu.RUnlock()
+	t, ok := schemas[key]
+	return t, ok
+}
+
+func registeredSchemas() map[string]reflect.Type {
+	schemaMu.RLock()
+	defer schemaMu.RUnlock()
+	out := make(map[string]reflect.Type, len(schemas))
+	for k, t := range schemas {
+		out[k] = t
+	}
+	return out
+}
+
+// checkLayer validates one parsed file against LiliumConfig and the
+// registered Extras schemas. Values of nodes in secrets are kept out of
+// error messages.
+func checkLayer(root *yaml.Node, file string, secrets map[*yaml.Node]bool) error {
+	c := &schemaChecker{file: file, secrets: secrets}
+	c.check(root, reflect.TypeOf(LiliumConfig{}), "", true)
+	if len(c.errs) > 0 {
+		return c.errs
+	}
+	return nil
+}
+
+type schemaChecker struct {
+	file    string
+	secrets map[*yaml.Node]bool
+	errs    ValidationErrors
+}
+
+func (c *schemaChecker) fail(n *yaml.Node, path, format string, args ...any) {
+	c.errs = append(c.errs, &FieldError{
+		Pos:  Position{File: c.file, Line: n.Line, Column: n.Column},
+		Path: path,
+		Msg:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *schemaChecker) check(n *yaml.Node, t reflect.Type, path string, top bool) {
+	if n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	if n.Tag == "!!null" {
+		return
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if t.PkgPath() == "time" || reflect.PointerTo(t).Implements(unmarshalerType) {
+			c.checkScalar(n, t, path)
+			return
+		}
+		if n.Kind != yaml.MappingNode {
+			c.fail(n, path, "expected a mapping, got %s", c.kindName(n))
+			return
+		}
+		fields, inline := yamlFields(t)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, val := n.Content[i], n.Content[i+1]
+			childPath := joinPath(path, key.Value)
+			if f, ok := fields[key.Value]; ok {
+				c.check(val, f.Type, childPath, false)
+				continue
+			}
+			if inline && top {
+				if schema, ok := lookupSchema(key.Value); ok {
+					c.checkExtra(val, schema, childPath)
+				}
+				continue
+			}
+			if inline {
+				continue
+			}
+			msg := "unknown key"
+			if s := suggest(key.Value, fields); s != "" {
+				msg += fmt.Sprintf(", did you mean %q?", s)
+			}
+			c.fail(key, childPath, "%s", msg)
+		}
+
+	case reflect.Map:
+		if n.Kind != yaml.MappingNode {
+			c.fail(n, path, "expected a mapping, got %s", c.kindName(n))
+			return
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			c.check(n.Content[i+1], t.Elem(), joinPath(path, n.Content[i].Value), false)
+		}
+
+	case reflect.Slice, reflect.Array:
+		if n.Kind != yaml.SequenceNode {
+			c.fail(n, path, "expected a list, got %s", c.kindName(n))
+			return
+		}
+		for i, item := range n.Content {
+			c.check(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), false)
+		}
+
+	case reflect.Interface:
+		// any value is accepted
+
+	default:
+		c.checkScalar(n, t, path)
+	}
+}
+
+// checkExtra validates an Extras section against its registered schema.
+// The schema's own Validate method runs once the layers are merged, since a
+// single layer may set only part of the section.
+func (c *schemaChecker) checkExtra(n *yaml.Node, schema reflect.Type, path string) {
+	before := len(c.errs)
+	c.check(n, schema, path, false)
+	if len(c.errs) > before {
+		return
+	}
+
+	if err := n.Decode(reflect.New(schema).Interface()); err != nil {
+		c.fail(n, path, "%s", yamlErrorMessage(err))
+	}
+}
+
+var unmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+
+func (c *schemaChecker) checkScalar(n *yaml.Node, t reflect.Type, path string) {
+	if n.Kind != yaml.ScalarNode && t.Kind() != reflect.Struct {
+		c.fail(n, path, "expected a %s, got %s", t.Kind(), c.kindName(n))
+		return
+	}
+	if err := n.Decode(reflect.New(t).Interface()); err != nil {
+		if c.secrets[n] {
+			c.fail(n, path, "secret value is not a valid %s", t)
+			return
+		}
+		c.fail(n, path, "%s", yamlErrorMessage(err))
+	}
+}
+
+var yamlLinePrefix = regexp.MustCompile(`^(yaml: unmarshal errors:\s*)?(line \d+: )?`)
+
+// yamlErrorMessage strips the location prefix yaml.v3 adds, since
+// FieldError carries its own position.
+func yamlErrorMessage(err error) string {
+	return yamlLinePrefix.ReplaceAllString(strings.TrimSpace(err.Error()), "")
+}
+
+func (c *schemaChecker) kindName(n *yaml.Node) string {
+	switch {
+	case n.Kind == yaml.MappingNode:
+		return "a mapping"
+	case n.Kind == yaml.SequenceNode:
+		return "a list"
+	case c.secrets[n]:
+		return "a secret value"
+	default:
+		return fmt.Sprintf("%q", n.Value)
+	}
+}
+
+// yamlFields maps the YAML names of t's fields to the fields, and reports
+// whether t has an inline map that absorbs unknown keys.
+func yamlFields(t reflect.Type) (map[string]reflect.StructField, bool) {
+	fields := make(map[string]reflect.StructField)
+	inline := false
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("yaml")
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(opts, "inline") {
+			if f.Type.Kind() == reflect.Map {
+				inline = true
+				continue
+			}
+			embedded, embeddedInline := yamlFields(f.Type)
+			for k, v := range embedded {
+				fields[k] = v
+			}
+			inline = inline || embeddedInline
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f
+	}
+	return fields, inline
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// suggest returns the known key closest to key when it looks like a typo.
+func suggest(key string, fields map[string]reflect.StructField) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	best, bestDist := "", len(key)/3+1
+	for _, name := range names {
+		if d := editDistance(strings.ToLower(key), strings.ToLower(name)); d < bestDist {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// recordPositions walks the merged tree and stores in cfg where each value
+// came from, using the file each node was read from, which values were
+// produced by secret resolvers, and the references values were expanded
+// from.
+func recordPositions(n *yaml.Node, path string, st *loadState, cfg *LiliumConfig) {
+	if n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	if path != "" {
+		cfg.positions[path] = Position{File: st.files[n], Line: n.Line, Column: n.Column}
+		if st.secrets[n] {
+			cfg.secrets[path] = true
+		}
+		if expr, ok := st.exprs[n]; ok {
+			cfg.exprs[path] = expr
+		}
+	}
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			recordPositions(n.Content[i+1], joinPath(path, n.Content[i].Value), st, cfg)
+		}
+	case yaml.SequenceNode:
+		for i, item := range n.Content {
+			recordPositions(item, fmt.Sprintf("%s[%d]", path, i), st, cfg)
+		}
+	}
+}
+
+// IsSecret reports whether the value at path was produced by a secret
+// resolver such as ${file:...}, so dumps and logs can mask it.
+func (c *LiliumConfig) IsSecret(path string) bool {
+	return c.secrets[path]
+}
+
+// tagNodes records file as the source of n and all of its descendants.
+func tagNodes(n *yaml.Node, file string, files map[*yaml.Node]string) {
+	files[n] = file
+	for _, c := range n.Content {
+		tagNodes(c, file, files)
+	}
+}
+
+// Position returns where the value at path (e.g. "server.port" or
+// "server.static[0].directory") was set. Values filled in by defaults have
+// no position.
+func (c *LiliumConfig) Position(path string) (Position, bool) {
+	p, ok := c.positions[path]
+	return p, ok
+}
+
+func (c *LiliumConfig) fieldError(path, format string, args ...any) *FieldError {
+	return &FieldError{Pos: c.positions[path], Path: path, Msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLoad_UnknownKeyInKnownSection(t *testing.T) {
+	path := writeTempFile(t, "lilium.yaml", `
+server:
+  cors:
+    allowedMetods: ["GET"]
+`)
+
+	_, err := Load(path)
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) || len(verrs) != 1 {
+		t.Fatalf("expected one validation error, got %v", err)
+	}
+	e := verrs[0]
+	if e.Path != "server.cors.allowedMetods" || e.Pos.Line != 4 || e.Pos.Column != 5 || e.Pos.File != path {
+		t.Fatalf("unexpected error location: %+v", e)
+	}
+	if !strings.Contains(e.Msg, `did you mean "allowedMethods"?`) {
+		t.Fatalf("expected a suggestion, got %q", e.Msg)
+	}
+}
+
+func TestLoad_TypeErrorsCarryPositions(t *testing.T) {
+	path := writeTempFile(t, "lilium.yaml", `
+server:
+  port: eighty
+logger:
+  toFile: maybe
+`)
+
+	_, err := Load(path)
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) || len(verrs) != 2 {
+		t.Fatalf("expected two validation errors, got %v", err)
+	}
+	if verrs[0].Path != "server.port" || verrs[0].Pos.Line != 3 || verrs[0].Pos.Column != 9 {
+		t.Fatalf("unexpected first error: %+v", verrs[0])
+	}
+	if verrs[1].Path != "logger.toFile" || verrs[1].Pos.Line != 5 {
+		t.Fatalf("unexpected second error: %+v", verrs[1])
+	}
+}
+
+type testMailerConfig struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+}
+
+func (m testMailerConfig) Validate() error {
+	if m.Host == "" {
+		return fmt.Errorf("host is required")
+	}
+	return nil
+}
+
+func TestLoad_RegisteredExtraSchema(t *testing.T) {
+	RegisterSchema("mailer", testMailerConfig{})
+	defer func() {
+		schemaMu.Lock()
+		delete(schemas, "mailer")
+		schemaMu.Unlock()
+	}()
+
+	cases := map[string]string{
+		"mailer:\n  host: smtp.example.com\n  prot: 25\n": "mailer.prot: unknown key",
+		"mailer:\n  port: 25\n":                           "mailer: host is required",
+	}
+	for content, want := range cases {
+		_, err := Load(writeTempFile(t, "lilium.yaml", content))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected %q, got %v", want, err)
+		}
+	}
+
+	cfg, err := Load(writeTempFile(t, "lilium.yaml", "mailer:\n  host: smtp.example.com\n  port: 25\nother:\n  free: form\n"))
+	if err != nil {
+		t.Fatalf("Load returned error for a valid extra: %v", err)
+	}
+	if _, ok := cfg.Extras["other"]; !ok {
+		t.Fatal("expected unregistered extras to stay free-form")
+	}
+}
+
+func TestValidate_RangeAndDirectories(t *testing.T) {
+	path := writeTempFile(t, "lilium.yaml", `
+server:
+  port: 70000
+  static:
+    - route: /
+      directory: ./definitely-missing
+`)
+
+	_, err := Load(path)
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) || len(verrs) != 2 {
+		t.Fatalf("expected Load to return two validation errors, got %v", err)
+	}
+	if verrs[0].Path != "server.port" || verrs[0].Pos.Line != 3 {
+		t.Fatalf("unexpected port error: %+v", verrs[0])
+	}
+	if verrs[1].Path != "server.static[0].directory" || verrs[1].Pos.Line != 6 {
+		t.Fatalf("unexpected directory error: %+v", verrs[1])
+	}
+}
+
+func TestPositionNamesLayer(t *testing.T) {
+	path := writeLayers(t, map[string]string{
+		"lilium.yaml":       "server:\n  port: 8000\n",
+		"lilium.local.yaml": "\nserver:\n  port: 9000\n",
+	})
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+
+	pos, ok := cfg.Position("server.port")
+	if !ok || !strings.HasSuffix(pos.File, "lilium.local.yaml") || pos.Line != 3 {
+		t.Fatalf("expected server.port from lilium.local.yaml:3, got %+v", pos)
+	}
+}
+
+func TestValidate_Cors(t *testing.T) {
+	path := writeTempFile(t, "lilium.yaml", `
+server:
+  cors:
+    enabled: true
+    origins: ["*", "https://*.*.example.com"]
+    originPatterns: ["https://(unclosed"]
+    allowCredentials: true
+`)
+
+	cfg, err := LoadWithOptions(path, LoadOptions{NoValidate: true})
+	if err != nil {
+		t.Fatalf("LoadWithOptions returned error: %v", err)
+	}
+
+	var verrs ValidationErrors
+	if err := cfg.Validate(); !errors.As(err, &verrs) || len(verrs) != 3 {
+		t.Fatalf("expected three validation errors, got %v", err)
+	}
+	want := map[string]int{
+		"server.cors.origins[1]":        5,
+		"server.cors.originPatterns[0]": 6,
+		"server.cors.allowCredentials":  7,
+	}
+	for _, e := range verrs {
+		if line, ok := want[e.Path]; !ok || e.Pos.Line != line {
+			t.Errorf("unexpected error %+v", e)
+		}
+	}
+
+	cfg.Server.Cors.Enabled = false
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected a disabled policy to be ignored, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Validate checks values that decode fine but cannot work at runtime: port
+// range, static directories that do not exist, malformed routes and unsafe
+// or broken CORS policies. Errors
+// carry the file, line and column the value came from. The result is nil or
+// a ValidationErrors.
+func (c *LiliumConfig) Validate() error {
+	var errs ValidationErrors
+
+	if c.Server != nil {
+		if c.Server.Port < 1 || c.Server.Port > 65535 {
+			errs = append(errs, c.fieldError("server.port", "%d is out of range 1-65535", c.Server.Port))
+		}
+		for i, s := range c.Server.Static {
+			path := fmt.Sprintf("server.static[%d].directory", i)
+			if s.Directory == "" {
+				errs = append(errs, c.fieldError(path, "must not be empty"))
+				continue
+			}
+			info, err := os.Stat(s.Directory)
+			switch {
+			case err != nil:
+				errs = append(errs, c.fieldError(path, "directory %q does not exist", s.Directory))
+			case !info.IsDir():
+				errs = append(errs, c.fieldError(path, "%q is not a directory", s.Directory))
+			}
+		}
+		if cors := c.Server.Cors; cors != nil && cors.Enabled {
+			var corsErrs ValidationErrors
+			if errors.As(cors.Validate(), &corsErrs) {
+				for _, e := range corsErrs {
+					errs = append(errs, c.fieldError("server.cors."+e.Path, "%s", e.Msg))
+				}
+			}
+		}
+		if a := c.Server.Admin; a != nil && a.Enabled && !strings.HasPrefix(a.Route, "/") {
+			errs = append(errs, c.fieldError("server.admin.route", "%q must start with /", a.Route))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Validate checks a CORS policy: origins may hold at most one * wildcard,
+// origin patterns must compile, and credentials cannot be combined with an
+// origin list that allows everyone. Paths in the errors are relative to the
+// policy, e.g. "origins[1]".
+func (c *CorsConfig) Validate() error {
+	var errs ValidationErrors
+	allowAll := len(c.Origins) == 0 && len(c.OriginPatterns) == 0
+	for i, o := range c.Origins {
+		if o == "*" {
+			allowAll = true
+		} else if strings.Count(o, "*") > 1 {
+			errs = append(errs, &FieldError{Path: fmt.Sprintf("origins[%d]", i), Msg: fmt.Sprintf("%q may contain at most one * wildcard", o)})
+		}
+	}
+	for i, p := range c.OriginPatterns {
+		if _, err := regexp.Compile(p); err != nil {
+			errs = append(errs, &FieldError{Path: fmt.Sprintf("originPatterns[%d]", i), Msg: err.Error()})
+		}
+	}
+	if c.AllowCredentials && allowAll {
+		errs = append(errs, &FieldError{Path: "allowCredentials", Msg: "cannot be combined with an origin list that allows every origin; list the allowed origins"})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
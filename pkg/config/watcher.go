@@ -0,0 +1,289 @@
+package config
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ChangeFunc is called after a reload changed the section it subscribed to.
+type ChangeFunc func(old, new *LiliumConfig)
+
+// Watcher reloads a LiliumConfig when its files change and tells
+// subscribers which sections changed. Reloads that fail to parse or
+// validate are rejected and the previous config stays in place.
+type Watcher struct {
+	path    string
+	opts    LoadOptions
+	current atomic.Pointer[LiliumConfig]
+
+	reloadMu   sync.Mutex // serializes reloads
+	files      map[string]fileState
+	validators []func(*LiliumConfig) error
+
+	mu      sync.Mutex
+	subs    map[int]subscription
+	nextID  int
+	onError func(error)
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+type subscription struct {
+	section []string
+	fn      ChangeFunc
+}
+
+type fileState struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+	hash    [sha256.Size]byte
+}
+
+// NewWatcher watches the files cfg was loaded from, using the same profile
+// and options. cfg must come from Load or LoadWithOptions.
+func NewWatcher(cfg *LiliumConfig) (*Watcher, error) {
+	if cfg == nil || cfg.path == "" {
+		return nil, errors.New("config watcher: config was not loaded from a file")
+	}
+	if cfg.options.FS != nil {
+		return nil, errors.New("config watcher: configs loaded from an fs.FS cannot be watched")
+	}
+
+	w := &Watcher{
+		path:  cfg.path,
+		opts:  cfg.options,
+		files: make(map[string]fileState),
+		subs:  make(map[int]subscription),
+		stop:  make(chan struct{}),
+	}
+	w.current.Store(cfg)
+	w.scan()
+	return w, nil
+}
+
+// Config returns the current config. Callers must treat it as read-only.
+func (w *Watcher) Config() *LiliumConfig {
+	return w.current.Load()
+}
+
+// AddValidator registers a check that every reloaded config must pass in
+// addition to LiliumConfig.Validate.
+func (w *Watcher) AddValidator(fn func(*LiliumConfig) error) {
+	w.reloadMu.Lock()
+	w.validators = append(w.validators, fn)
+	w.reloadMu.Unlock()
+}
+
+// OnError sets the function that receives rejected reloads.
+func (w *Watcher) OnError(fn func(error)) {
+	w.mu.Lock()
+	w.onError = fn
+	w.mu.Unlock()
+}
+
+// Subscribe calls fn after every reload that changes section, a dotted path
+// such as "logger", "server.cors" or an Extras key like "auth". An empty
+// section matches any change. The returned function unsubscribes.
+func (w *Watcher) Subscribe(section string, fn ChangeFunc) func() {
+	var path []string
+	if section != "" {
+		path = strings.Split(section, ".")
+	}
+
+	w.mu.Lock()
+	id := w.nextID
+	w.nextID++
+	w.subs[id] = subscription{section: path, fn: fn}
+	w.mu.Unlock()
+
+	return func() {
+		w.mu.Lock()
+		delete(w.subs, id)
+		w.mu.Unlock()
+	}
+}
+
+// Reload re-reads every layer, re-applies env expansion, defaults and
+// validation, and swaps the result in. On error the current config is kept
+// and the error is also passed to the OnError function.
+func (w *Watcher) Reload() error {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	next, err := LoadWithOptions(w.path, w.opts)
+	for _, v := range w.validators {
+		if err != nil {
+			break
+		}
+		err = v(next)
+	}
+	if err != nil {
+		err = fmt.Errorf("config reload rejected: %w", err)
+		w.reportError(err)
+		return err
+	}
+
+	prev := w.current.Swap(next)
+	w.notify(prev, next)
+	return nil
+}
+
+func (w *Watcher) reportError(err error) {
+	w.mu.Lock()
+	fn := w.onError
+	w.mu.Unlock()
+	if fn != nil {
+		fn(err)
+	}
+}
+
+func (w *Watcher) notify(prev, next *LiliumConfig) {
+	w.mu.Lock()
+	subs := make([]subscription, 0, len(w.subs))
+	for _, s := range w.subs {
+		subs = append(subs, s)
+	}
+	w.mu.Unlock()
+	if len(subs) == 0 {
+		return
+	}
+
+	oldTree, newTree := configTree(prev), configTree(next)
+	for _, s := range subs {
+		if !reflect.DeepEqual(lookupTree(oldTree, s.section), lookupTree(newTree, s.section)) {
+			s.fn(prev, next)
+		}
+	}
+}
+
+// configTree renders cfg as the generic map its YAML would decode to, with
+// Extras at the top level next to the typed sections.
+func configTree(cfg *LiliumConfig) map[string]any {
+	tree := make(map[string]any)
+	if b, err := yaml.Marshal(cfg); err == nil {
+		_ = yaml.Unmarshal(b, &tree)
+	}
+	return tree
+}
+
+func lookupTree(tree map[string]any, path []string) any {
+	var cur any = tree
+	for _, key := range path {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = m[key]
+	}
+	return cur
+}
+
+// Start polls the config files every interval and reloads when one of them
+// was created, removed or changed content.
+func (w *Watcher) Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	w.done = make(chan struct{})
+
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if w.scan() {
+					_ = w.Reload()
+				}
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+}
+
+// ReloadOnSignal reloads every time one of sigs is received. The returned
+// function stops listening.
+func (w *Watcher) ReloadOnSignal(sigs ...os.Signal) func() {
+	if len(sigs) == 0 {
+		return func() {}
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ch:
+				w.scan()
+				_ = w.Reload()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// Stop ends polling started by Start.
+func (w *Watcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+		if w.done != nil {
+			<-w.done
+		}
+	})
+}
+
+// scan refreshes the recorded state of every layer file and reports whether
+// any of them changed. Files whose mtime moved are hashed, so touching a
+// file without changing it does not trigger a reload.
+func (w *Watcher) scan() bool {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	changed := false
+	for _, p := range LayerPaths(w.path, w.opts.Profile, w.opts.NoLocal) {
+		prev, seen := w.files[p]
+		next := fileState{}
+
+		info, err := os.Stat(p)
+		if err == nil {
+			next = fileState{exists: true, modTime: info.ModTime(), size: info.Size(), hash: prev.hash}
+			if !prev.exists || !prev.modTime.Equal(next.modTime) || prev.size != next.size {
+				if data, err := os.ReadFile(p); err == nil {
+					next.hash = sha256.Sum256(data)
+				}
+			}
+		}
+
+		if seen && (prev.exists != next.exists || prev.hash != next.hash) {
+			changed = true
+		}
+		w.files[p] = next
+	}
+	return changed
+}
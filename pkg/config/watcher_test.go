@@ -0,0 +1,118 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatcher_ReloadNotifiesChangedSections(t *testing.T) {
+	path := writeLayers(t, map[string]string{
+		"lilium.yaml": "server:\n  port: 8000\nauth:\n  provider: google\n",
+	})
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	w, err := NewWatcher(cfg)
+	if err != nil {
+		t.Fatalf("NewWatcher returned error: %v", err)
+	}
+
+	var serverCalls, authCalls, anyCalls atomic.Int32
+	w.Subscribe("server", func(old, new *LiliumConfig) {
+		if old.Server.Port != 8000 || new.Server.Port != 9000 {
+			t.Errorf("unexpected ports old=%d new=%d", old.Server.Port, new.Server.Port)
+		}
+		serverCalls.Add(1)
+	})
+	w.Subscribe("auth", func(_, _ *LiliumConfig) { authCalls.Add(1) })
+	w.Subscribe("", func(_, _ *LiliumConfig) { anyCalls.Add(1) })
+
+	local := filepath.Join(filepath.Dir(path), "lilium.local.yaml")
+	if err := os.WriteFile(local, []byte("server:\n  port: 9000\n"), 0644); err != nil {
+		t.Fatalf("failed to write local layer: %v", err)
+	}
+	if !w.scan() {
+		t.Fatal("expected the new local layer to be detected")
+	}
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Reload returned error: %v", err)
+	}
+
+	if w.Config().Server.Port != 9000 {
+		t.Fatalf("expected port 9000 after reload, got %d", w.Config().Server.Port)
+	}
+	if serverCalls.Load() != 1 || anyCalls.Load() != 1 || authCalls.Load() != 0 {
+		t.Fatalf("unexpected notifications: server=%d auth=%d any=%d", serverCalls.Load(), authCalls.Load(), anyCalls.Load())
+	}
+}
+
+func TestWatcher_RejectsInvalidReload(t *testing.T) {
+	path := writeLayers(t, map[string]string{"lilium.yaml": "server:\n  port: 8000\n"})
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	w, err := NewWatcher(cfg)
+	if err != nil {
+		t.Fatalf("NewWatcher returned error: %v", err)
+	}
+
+	var reported error
+	w.OnError(func(err error) { reported = err })
+
+	for _, bad := range []string{"server:\n  port: nope\n", "server:\n  port: 70000\n"} {
+		if err := os.WriteFile(path, []byte(bad), 0644); err != nil {
+			t.Fatalf("failed to rewrite config: %v", err)
+		}
+		if err := w.Reload(); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+		if reported == nil || !strings.Contains(reported.Error(), "rejected") {
+			t.Fatalf("expected the rejection to be reported, got %v", reported)
+		}
+		if w.Config() != cfg {
+			t.Fatal("expected the previous config to stay in place")
+		}
+	}
+}
+
+func TestWatcher_PollsForChanges(t *testing.T) {
+	path := writeLayers(t, map[string]string{"lilium.yaml": "name: before\n"})
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	w, err := NewWatcher(cfg)
+	if err != nil {
+		t.Fatalf("NewWatcher returned error: %v", err)
+	}
+
+	changed := make(chan string, 1)
+	w.Subscribe("name", func(_, new *LiliumConfig) { changed <- new.Name })
+	w.Start(10 * time.Millisecond)
+	defer w.Stop()
+
+	if err := os.WriteFile(path, []byte("name: after-the-change\n"), 0644); err != nil {
+		t.Fatalf("failed to rewrite config: %v", err)
+	}
+
+	select {
+	case name := <-changed:
+		if name != "after-the-change" {
+			t.Fatalf("expected the new name, got %s", name)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the reload")
+	}
+}
+
+func TestNewWatcher_RequiresFile(t *testing.T) {
+	if _, err := NewWatcher(&LiliumConfig{}); err == nil {
+		t.Fatal("expected an error for a config that was not loaded from a file")
+	}
+}
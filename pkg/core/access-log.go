@@ -0,0 +1,42 @@
+package core
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/spyder01/lilium-go/pkg/logger"
+)
+
+// accessLogHandler records every request in the dedicated access log. It
+// installs the shared ResponseRecorder, so middleware further down the chain
+// reuses it instead of wrapping the writer again.
+func accessLogHandler(al *logger.AccessLogger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if al.Skip(req.URL.Path) {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			start := time.Now()
+			rr := CaptureResponse(w)
+			next.ServeHTTP(rr, req)
+
+			user, _, _ := req.BasicAuth()
+			al.Log(&logger.AccessEntry{
+				Time:           start,
+				RemoteAddr:     req.RemoteAddr,
+				User:           user,
+				Method:         req.Method,
+				Path:           req.URL.Path,
+				RawQuery:       req.URL.RawQuery,
+				Proto:          req.Proto,
+				Status:         rr.Status(),
+				Size:           rr.Size(),
+				Duration:       time.Since(start),
+				RequestHeader:  req.Header,
+				ResponseHeader: rr.Header(),
+			})
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spyder01/lilium-go/pkg/config"
+)
+
+func TestCaptureResponseReusesRecorder(t *testing.T) {
+	rr := CaptureResponse(httptest.NewRecorder())
+	if again := CaptureResponse(rr); again != rr {
+		t.Fatalf("expected CaptureResponse to reuse an existing recorder")
+	}
+}
+
+func TestRouterWritesAccessLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	cfg := &config.LiliumConfig{
+		Logger: &config.LogConfig{Access: &config.AccessLogConfig{
+			Enabled:   true,
+			Format:    "common",
+			FilePath:  path,
+			SkipPaths: []string{"/healthz"},
+		}},
+	}
+	app := New(cfg, context.Background())
+
+	router := NewRouter(app.Context)
+	router.GET("/hello", func(c *RequestContext) error {
+		return c.Text(http.StatusCreated, "hi")
+	})
+	router.GET("/healthz", func(c *RequestContext) error {
+		return c.Text(http.StatusOK, "ok")
+	})
+
+	for _, p := range []string{"/hello", "/healthz"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", p, nil))
+	}
+
+	if err := app.AccessLog.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read access log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], `"GET /hello HTTP/1.1" 201 2`) {
+		t.Fatalf("unexpected access log: %v", lines)
+	}
+}
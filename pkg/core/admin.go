@@ -0,0 +1,75 @@
+package core
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/spyder01/lilium-go/pkg/config"
+	"github.com/spyder01/lilium-go/pkg/logger"
+)
+
+// processAdmin mounts the operational endpoints under Server.Admin.Route
+// when they are enabled.
+func (app *Lilium) processAdmin(r chi.Router) {
+	if app.Config.Server == nil || app.Config.Server.Admin == nil || !app.Config.Server.Admin.Enabled {
+		return
+	}
+
+	adminCfg := app.Config.Server.Admin
+
+	admin := chi.NewRouter()
+	if adminCfg.Token != "" {
+		admin.Use(requireBearerToken(adminCfg.Token))
+	} else {
+		app.Logger.Warn("Admin endpoints are enabled without a token")
+	}
+
+	admin.Handle("/log/level", logger.LevelHandler(app.Logger))
+	admin.Get("/config", app.configHandler)
+
+	r.Mount(adminCfg.Route, admin)
+	app.Logger.Infof("Mounted admin endpoints at %s", adminCfg.Route)
+}
+
+// configHandler serves the effective config, annotated with where each
+// value came from. ?format=json returns a list of entries instead of YAML.
+// Secrets, and values under the default and configured sensitive keys, are
+// always masked.
+func (app *Lilium) configHandler(w http.ResponseWriter, req *http.Request) {
+	cfg := app.CurrentConfig()
+	var redact *config.RedactConfig
+	if cfg.Logger != nil {
+		redact = cfg.Logger.Redact
+	}
+	format := config.DumpFormat(req.URL.Query().Get("format"))
+	out, err := config.Dump(cfg, config.DumpOptions{
+		Format:  format,
+		MaskKey: logger.SensitiveKey(redact),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if format == config.DumpJSON {
+		w.Header().Set("Content-Type", "application/json")
+	} else {
+		w.Header().Set("Content-Type", "application/yaml")
+	}
+	_, _ = w.Write(out)
+}
+
+func requireBearerToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			got, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
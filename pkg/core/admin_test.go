@@ -0,0 +1,123 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/spyder01/lilium-go/pkg/config"
+)
+
+func TestAdminConfigEndpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lilium.yaml")
+	data := `server:
+  port: ${ADMIN_TEST_PORT:9000}
+  admin:
+    enabled: true
+    token: s3cret-admin
+db:
+  password: hunter2
+`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadWithOptions(path, config.LoadOptions{NoLocal: true, NoEnv: true})
+	if err != nil {
+		t.Fatalf("LoadWithOptions: %v", err)
+	}
+
+	app := New(cfg, context.Background())
+	mux := chi.NewRouter()
+	app.processAdmin(mux)
+
+	req := httptest.NewRequest("GET", "/_lilium/config", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a token, got %d", rec.Code)
+	}
+
+	req.Header.Set("Authorization", "Bearer s3cret-admin")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, body)
+	}
+	for _, want := range []string{"port: 9000 # " + path + ":2:9 ${ADMIN_TEST_PORT:9000}", "route: /_lilium # default"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "s3cret-admin") || strings.Contains(body, "hunter2") {
+		t.Fatalf("secrets leaked:\n%s", body)
+	}
+
+	req = httptest.NewRequest("GET", "/_lilium/config?format=json", nil)
+	req.Header.Set("Authorization", "Bearer s3cret-admin")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" || !strings.Contains(rec.Body.String(), `"path": "server.port"`) {
+		t.Fatalf("unexpected JSON dump (%s):\n%s", ct, rec.Body.String())
+	}
+}
+
+func TestAdminConfigEndpoint_MasksPlainKeysNextToSecrets(t *testing.T) {
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "api_key")
+	if err := os.WriteFile(secretFile, []byte("file-s3cret-value\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "lilium.yaml")
+	data := "server:\n  admin:\n    enabled: true\n" +
+		"logger:\n  redact:\n    noDefaults: true\n" +
+		"payments:\n  apiKey: ${file:" + secretFile + "}\n" +
+		"db:\n  password: hunter2\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadWithOptions(path, config.LoadOptions{NoLocal: true, NoEnv: true})
+	if err != nil {
+		t.Fatalf("LoadWithOptions: %v", err)
+	}
+	if cfg.Logger.Redact == nil {
+		t.Fatal("expected the secret to configure log redaction")
+	}
+
+	app := New(cfg, context.Background())
+	mux := chi.NewRouter()
+	app.processAdmin(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/_lilium/config", nil))
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, body)
+	}
+	if strings.Contains(body, "file-s3cret-value") || strings.Contains(body, "hunter2") {
+		t.Fatalf("secrets leaked:\n%s", body)
+	}
+}
+
+func TestAdminConfigEndpoint_ConfigWithoutLogger(t *testing.T) {
+	app := New(&config.LiliumConfig{
+		Name:   "bare",
+		Server: &config.ServerConfig{Admin: &config.AdminConfig{Enabled: true, Route: "/_lilium"}},
+	}, context.Background())
+	if app.AccessLog != nil {
+		t.Fatal("expected no access log without a logger section")
+	}
+	mux := chi.NewRouter()
+	app.processAdmin(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/_lilium/config", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "name: bare") {
+		t.Fatalf("expected the config dump, got %d:\n%s", rec.Code, rec.Body.String())
+	}
+}
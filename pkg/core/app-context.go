@@ -15,6 +15,7 @@ type Context struct {
 	Logger    *logger.Logger
 	app       *Lilium
 	Ctx       context.Context
+	di        *container // typed dependencies, see Provide
 }
 
 func (ctx *Context) Set(key string, val any) {
@@ -143,10 +144,17 @@ func (ctx *Context) GetLocal(key string) (any, bool) {
 	return ctx.Get("local." + key)
 }
 
+// Provide stores val under a string key.
+//
+// Deprecated: use the typed Provide[T] function, which cannot collide on
+// names and needs no type assertions.
 func (ctx *Context) Provide(key string, val any) {
 	ctx.Set("di."+key, val)
 }
 
+// Resolve returns the value stored by the Provide method.
+//
+// Deprecated: use the typed Resolve[T] function.
 func (ctx *Context) Resolve(key string) (any, bool) {
 	return ctx.Get("di." + key)
 }
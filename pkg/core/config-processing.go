@@ -3,42 +3,30 @@ package core
 import (
 	"fmt"
 
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/cors"
-	"github.com/joho/godotenv"
 	"github.com/spyder01/lilium-go/pkg/config"
 )
 
-func (app *Lilium) processCors(r chi.Router) {
-	if app.Config.Server == nil || app.Config.Server.Cors == nil {
-		return
-	}
-
-	corsCfg := app.Config.Server.Cors
-
-	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   corsCfg.Origins,
-		AllowedMethods:   corsCfg.AllowedMethods,
-		AllowedHeaders:   corsCfg.AllowedHeaders,
-		MaxAge:           int(corsCfg.MaxAge),
-		ExposedHeaders:   corsCfg.ExposedHeaders,
-		AllowCredentials: corsCfg.AllowCredentials,
-	}))
-}
-
-func processEnv(envCfg *config.EnvironmentConfig) {
+// processEnv loads the .env files enabled by envCfg into the process
+// environment, so the config can reference their variables.
+func processEnv(envCfg *config.EnvironmentConfig, profile string, noLocal bool) error {
 	if envCfg == nil || !envCfg.EnableFile {
-		return
+		return nil
 	}
 
-	if envCfg.FilePath == "" {
-		envCfg.FilePath = ".env"
+	base := envCfg.FilePath
+	if base == "" {
+		base = ".env"
 	}
 
-	err := godotenv.Load(envCfg.FilePath)
+	_, err := config.LoadDotenv(base, config.DotenvOptions{
+		Profile:  profile,
+		NoLocal:  noLocal,
+		Override: envCfg.Override,
+	})
 	if err != nil {
-		panic(fmt.Sprintf("Error loading .env file: %v", err))
+		return fmt.Errorf("error loading .env files: %w", err)
 	}
+	return nil
 }
 
 /*
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spyder01/lilium-go/pkg/config"
+)
+
+func TestReadLiliumConfig_Dotenv(t *testing.T) {
+	dir := t.TempDir()
+	env := filepath.Join(dir, ".env")
+	path := filepath.Join(dir, "lilium.yaml")
+	files := map[string]string{
+		"lilium.yaml": "name: ${APP_NAME}\nenv:\n  enableFile: true\n  filePath: " + env + "\n",
+		".env":        "APP_NAME=base\n",
+		".env.test":   "APP_NAME=\"$APP_NAME-test\"\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("APP_NAME", "")
+	os.Unsetenv("APP_NAME")
+
+	cfg, err := ReadLiliumConfig(path, config.LoadOptions{Profile: "test"})
+	if err != nil {
+		t.Fatalf("ReadLiliumConfig: %v", err)
+	}
+	if cfg.Name != "base-test" {
+		t.Errorf("expected the name from the layered .env files, got %q", cfg.Name)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ".env.local"), []byte("APP_NAME=\"unterminated\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = ReadLiliumConfig(path, config.LoadOptions{Profile: "test"})
+	if err == nil || !strings.Contains(err.Error(), ".env.local:1: unterminated") {
+		t.Fatalf("expected a .env parse error, got %v", err)
+	}
+}
+
+func TestReadLiliumConfig_MissingDotenv(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "lilium.yaml")
+	content := "env:\n  enableFile: true\n  filePath: " + filepath.Join(dir, ".env") + "\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadLiliumConfig(path, config.LoadOptions{}); err != nil {
+		t.Fatalf("expected a missing .env to be skipped, got %v", err)
+	}
+}
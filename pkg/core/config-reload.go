@@ -0,0 +1,146 @@
+package core
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/spyder01/lilium-go/pkg/config"
+	"github.com/spyder01/lilium-go/pkg/logger"
+)
+
+// setupConfigWatcher creates the config watcher when reloading is enabled
+// and wires the parts of the app that follow config changes live: logger
+// levels here, CORS origins and static mounts through CurrentConfig.
+func (app *Lilium) setupConfigWatcher() {
+	cfg := app.Config
+	if cfg.Reload == nil || !cfg.Reload.Enabled {
+		return
+	}
+
+	w, err := config.NewWatcher(cfg)
+	if err != nil {
+		app.Logger.Warnf("Config reload disabled: %v", err)
+		return
+	}
+
+	w.AddValidator(func(c *config.LiliumConfig) error {
+		return logger.ValidateLevels(c.Logger)
+	})
+	w.OnError(func(err error) {
+		app.Logger.Errorf("%v; keeping the previous configuration", err)
+	})
+	w.Subscribe("logger", func(_, next *config.LiliumConfig) {
+		if err := app.Logger.ApplyConfig(next.Logger); err != nil {
+			app.Logger.Errorf("Unable to apply log levels: %v", err)
+		}
+	})
+	w.Subscribe("", func(_, next *config.LiliumConfig) {
+		// Secrets resolved again may have been rotated.
+		if next.Logger != nil && next.Logger.Redact != nil {
+			app.Logger.RedactValues(next.Logger.Redact.Values...)
+		}
+		app.Logger.Infof("Configuration reloaded from %s", strings.Join(next.Layers, ", "))
+	})
+
+	app.configWatcher = w
+}
+
+// watchConfig starts polling and listening for reload signals. The returned
+// function stops both.
+func (app *Lilium) watchConfig() func() {
+	w := app.configWatcher
+	if w == nil {
+		return func() {}
+	}
+
+	w.Start(app.Config.Reload.Interval)
+	stopSignal := w.ReloadOnSignal(reloadSignals...)
+	app.Logger.Infof("Watching configuration for changes every %s", app.Config.Reload.Interval)
+
+	return func() {
+		stopSignal()
+		w.Stop()
+	}
+}
+
+// CurrentConfig returns the latest successfully loaded config. Without
+// reloading it is app.Config.
+func (app *Lilium) CurrentConfig() *config.LiliumConfig {
+	if app.configWatcher != nil {
+		return app.configWatcher.Config()
+	}
+	return app.Config
+}
+
+// LiveConfig returns the config currently in effect. The app provides one
+// for constructors and injected structs that outlive a reload; the
+// *config.LiliumConfig it provides is the config the app was started with
+// and never changes.
+type LiveConfig func() *config.LiliumConfig
+
+// OnConfigChange calls fn whenever a reload changes section, a dotted path
+// such as "server.cors" or an Extras key. It is a no-op unless reload is
+// enabled. The returned function unsubscribes.
+func (ctx *Context) OnConfigChange(section string, fn config.ChangeFunc) func() {
+	if ctx.app == nil || ctx.app.configWatcher == nil {
+		return func() {}
+	}
+	return ctx.app.configWatcher.Subscribe(section, fn)
+}
+
+// staticHandler serves requests no route matched from the static
+// directories of the current config, so mounts follow reloads; the longest
+// matching route wins. Other requests go to notFound. File servers are built
+// once per config.
+func (app *Lilium) staticHandler(notFound http.Handler) http.HandlerFunc {
+	type compiled struct {
+		cfg    *config.LiliumConfig
+		mounts []staticMount
+	}
+	var cache atomic.Pointer[compiled]
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		cfg := app.CurrentConfig()
+		c := cache.Load()
+		if c == nil || c.cfg != cfg {
+			c = &compiled{cfg, newStaticMounts(cfg.Server.Static)}
+			cache.Store(c)
+		}
+
+		p := req.URL.Path
+		for _, m := range c.mounts {
+			switch {
+			case m.route == "/":
+				http.StripPrefix("/", m.files).ServeHTTP(w, req)
+				return
+			case p == m.route:
+				http.Redirect(w, req, m.route+"/", http.StatusMovedPermanently)
+				return
+			case strings.HasPrefix(p, m.route+"/"):
+				http.StripPrefix(m.route+"/", m.files).ServeHTTP(w, req)
+				return
+			}
+		}
+		notFound.ServeHTTP(w, req)
+	}
+}
+
+type staticMount struct {
+	route string
+	files http.Handler
+}
+
+// newStaticMounts normalizes the routes of static and sorts them longest
+// first.
+func newStaticMounts(static []config.StaticConfig) []staticMount {
+	mounts := make([]staticMount, len(static))
+	for i, s := range static {
+		mounts[i] = staticMount{route: "/" + strings.Trim(s.Route, "/"), files: http.FileServer(http.Dir(s.Directory))}
+	}
+	sort.SliceStable(mounts, func(i, j int) bool {
+		return len(mounts[i].route) > len(mounts[j].route)
+	})
+	return mounts
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spyder01/lilium-go/pkg/config"
+)
+
+func TestOriginMatcher_Allow(t *testing.T) {
+	m := newOriginMatcher([]string{"https://app.example.com", "https://*.preview.example.com"}, nil)
+	cases := map[string]bool{
+		"https://app.example.com":          true,
+		"https://APP.example.com":          true,
+		"https://pr-1.preview.example.com": true,
+		"https://evil.example.com":         false,
+	}
+	for origin, want := range cases {
+		if got := m.allow(origin); got != want {
+			t.Errorf("allow(%q) = %v, want %v", origin, got, want)
+		}
+	}
+	if !newOriginMatcher(nil, nil).allow("https://any.example") {
+		t.Error("expected an empty origin list to allow everything")
+	}
+}
+
+func TestServeStaticUsesLongestMount(t *testing.T) {
+	root, docs := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "index.txt"), []byte("root"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(docs, "index.txt"), []byte("docs"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	app := &Lilium{Config: &config.LiliumConfig{Server: &config.ServerConfig{Static: []config.StaticConfig{
+		{Route: "/", Directory: root},
+		{Route: "/docs", Directory: docs},
+	}}}}
+
+	serve := app.staticHandler(http.NotFoundHandler())
+	cases := map[string]string{"/index.txt": "root", "/docs/index.txt": "docs"}
+	for path, want := range cases {
+		rec := httptest.NewRecorder()
+		serve(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != http.StatusOK || rec.Body.String() != want {
+			t.Fatalf("GET %s: expected %q, got %d %q", path, want, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	serve(rec, httptest.NewRequest("GET", "/docs", nil))
+	if rec.Code != http.StatusMovedPermanently {
+		t.Fatalf("expected a redirect for /docs, got %d", rec.Code)
+	}
+}
+
+func TestStaticHandlerFallsBackToNotFound(t *testing.T) {
+	app := &Lilium{Config: &config.LiliumConfig{Server: &config.ServerConfig{Static: []config.StaticConfig{
+		{Route: "/docs", Directory: t.TempDir()},
+	}}}}
+	custom := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "custom 404", http.StatusNotFound)
+	})
+
+	rec := httptest.NewRecorder()
+	app.staticHandler(custom)(rec, httptest.NewRequest("GET", "/missing", nil))
+	if rec.Code != http.StatusNotFound || rec.Body.String() != "custom 404\n" {
+		t.Fatalf("expected the router's NotFound handler, got %d %q", rec.Code, rec.Body.String())
+	}
+}
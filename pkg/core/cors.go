@@ -0,0 +1,218 @@
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/cors"
+	"github.com/spyder01/lilium-go/pkg/config"
+)
+
+// corsPolicy applies one CORS configuration. A nil cors turns CORS off for
+// the routes that use the policy.
+type corsPolicy struct {
+	cors *cors.Cors
+}
+
+func newCorsPolicy(cfg *config.CorsConfig, allow func(origin string) bool) *corsPolicy {
+	if cfg == nil {
+		return &corsPolicy{}
+	}
+	return &corsPolicy{cors: cors.New(cors.Options{
+		AllowOriginFunc:  func(_ *http.Request, origin string) bool { return allow(origin) },
+		AllowedMethods:   cfg.AllowedMethods,
+		AllowedHeaders:   cfg.AllowedHeaders,
+		ExposedHeaders:   cfg.ExposedHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           int(cfg.MaxAge),
+	})}
+}
+
+func (p *corsPolicy) serve(w http.ResponseWriter, req *http.Request, next http.Handler) {
+	if p.cors == nil {
+		next.ServeHTTP(w, req)
+		return
+	}
+	p.cors.Handler(next).ServeHTTP(w, req)
+}
+
+// WithCors returns a router whose routes use policy instead of the global
+// server.cors settings; its Enabled field is ignored. A nil policy turns
+// CORS off for those routes. Groups and sub-routers created from the result
+// inherit the policy. Preflight requests are answered with the policy of
+// the route they ask about. It panics if policy is invalid.
+func (r *Router) WithCors(policy *config.CorsConfig) *Router {
+	var allow func(string) bool
+	if policy != nil {
+		if err := policy.Validate(); err != nil {
+			panic(fmt.Sprintf("invalid CORS policy: %v", err))
+		}
+		allow = newOriginMatcher(policy.Origins, policy.OriginPatterns).allow
+	}
+	rr := *r
+	rr.cors = newCorsPolicy(policy, allow)
+	return &rr
+}
+
+// corsRoutes records the routes registered with their own CORS policy, by
+// method and full pattern.
+type corsRoutes struct {
+	mu       sync.RWMutex
+	policies map[string]*corsPolicy
+}
+
+func newCorsRoutes() *corsRoutes {
+	return &corsRoutes{policies: make(map[string]*corsPolicy)}
+}
+
+func (c *corsRoutes) add(method, pattern string, p *corsPolicy) {
+	c.mu.Lock()
+	c.policies[method+" "+pattern] = p
+	c.mu.Unlock()
+}
+
+// lookup returns the policy of the route req targets, or nil. Preflight
+// requests are matched with the method they ask about.
+func (c *corsRoutes) lookup(mux *chi.Mux, req *http.Request) *corsPolicy {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.policies) == 0 {
+		return nil
+	}
+
+	method := req.Method
+	if m := req.Header.Get("Access-Control-Request-Method"); method == http.MethodOptions && m != "" {
+		method = strings.ToUpper(m)
+	}
+	pattern := mux.Find(chi.NewRouteContext(), method, req.URL.Path)
+	if pattern == "" {
+		return nil
+	}
+	return c.policies[method+" "+pattern]
+}
+
+// corsHandler wraps router with the global server.cors policy and the
+// policies set through Router.WithCors. It is applied around the router
+// rather than as middleware so routes may be registered before Start.
+// Origins of the global policy follow config reloads; its other options,
+// and whether it is enabled, are fixed at start.
+func (app *Lilium) corsHandler(router *Router) http.Handler {
+	var global *corsPolicy
+	if app.Config.Server != nil && app.Config.Server.Cors != nil && app.Config.Server.Cors.Enabled {
+		corsCfg := app.Config.Server.Cors
+		matcher := newOriginMatcher(corsCfg.Origins, corsCfg.OriginPatterns)
+		allow := matcher.allow
+		if app.configWatcher != nil {
+			allow = app.reloadingOriginMatcher(corsCfg, matcher)
+		}
+		global = newCorsPolicy(corsCfg, allow)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		policy := router.corsRoutes.lookup(router.mux, req)
+		if policy == nil {
+			policy = global
+		}
+		if policy == nil {
+			router.ServeHTTP(w, req)
+			return
+		}
+		policy.serve(w, req, router)
+	})
+}
+
+// reloadingOriginMatcher matches origins against the current config,
+// compiling each new server.cors section once.
+func (app *Lilium) reloadingOriginMatcher(initial *config.CorsConfig, m *originMatcher) func(string) bool {
+	type compiled struct {
+		cfg     *config.CorsConfig
+		matcher *originMatcher
+	}
+	var cache atomic.Pointer[compiled]
+	cache.Store(&compiled{initial, m})
+
+	return func(origin string) bool {
+		cfg := app.CurrentConfig().Server.Cors
+		if cfg == nil {
+			return false
+		}
+		c := cache.Load()
+		if c.cfg != cfg {
+			c = &compiled{cfg, newOriginMatcher(cfg.Origins, cfg.OriginPatterns)}
+			cache.Store(c)
+		}
+		return c.matcher.allow(origin)
+	}
+}
+
+// originMatcher matches request origins, case-insensitively, against
+// exact origins, "*", origins with one wildcard and regular expressions.
+// A wildcard stands for one or more host characters, so
+// "https://*.example.com" matches "https://a.b.example.com" but not
+// "https://example.com" or "https://evil.com/.example.com".
+type originMatcher struct {
+	all       bool
+	exact     map[string]bool
+	wildcards [][2]string
+	patterns  []*regexp.Regexp
+}
+
+func newOriginMatcher(origins, patterns []string) *originMatcher {
+	m := &originMatcher{all: len(origins) == 0 && len(patterns) == 0, exact: make(map[string]bool)}
+	for _, o := range origins {
+		o = strings.ToLower(o)
+		if o == "*" {
+			m.all = true
+		} else if prefix, suffix, ok := strings.Cut(o, "*"); ok {
+			m.wildcards = append(m.wildcards, [2]string{prefix, suffix})
+		} else {
+			m.exact[o] = true
+		}
+	}
+	for _, p := range patterns {
+		// Invalid patterns are reported by config validation.
+		if re, err := regexp.Compile(`(?i)^(?:` + p + `)$`); err == nil {
+			m.patterns = append(m.patterns, re)
+		}
+	}
+	return m
+}
+
+func (m *originMatcher) allow(origin string) bool {
+	if m.all {
+		return true
+	}
+	lower := strings.ToLower(origin)
+	if m.exact[lower] {
+		return true
+	}
+	for _, w := range m.wildcards {
+		if len(lower) > len(w[0])+len(w[1]) && strings.HasPrefix(lower, w[0]) && strings.HasSuffix(lower, w[1]) &&
+			isHostText(lower[len(w[0]):len(lower)-len(w[1])]) {
+			return true
+		}
+	}
+	for _, re := range m.patterns {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHostText reports whether s only holds characters of host names and
+// ports.
+func isHostText(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '.' && c != '-' && (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
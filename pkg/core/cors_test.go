@@ -0,0 +1,111 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spyder01/lilium-go/pkg/config"
+)
+
+func corsTestApp(cors *config.CorsConfig) *Lilium {
+	return &Lilium{Config: &config.LiliumConfig{Server: &config.ServerConfig{Cors: cors}}}
+}
+
+func corsRequest(h http.Handler, method, path, origin string, preflightMethod string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Origin", origin)
+	if preflightMethod != "" {
+		req.Header.Set("Access-Control-Request-Method", preflightMethod)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func okHandler(ctx *RequestContext) error {
+	ctx.Res.WriteHeader(http.StatusOK)
+	return nil
+}
+
+func TestCorsHandler_HonorsEnabled(t *testing.T) {
+	router := NewRouter(nil)
+	router.GET("/items", okHandler)
+
+	h := corsTestApp(&config.CorsConfig{Origins: []string{"https://app.example"}}).corsHandler(router)
+	rec := corsRequest(h, http.MethodGet, "/items", "https://app.example", "")
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("expected no CORS headers while disabled, got %q", got)
+	}
+
+	h = corsTestApp(&config.CorsConfig{Enabled: true, Origins: []string{"https://app.example"}}).corsHandler(router)
+	rec = corsRequest(h, http.MethodGet, "/items", "https://app.example", "")
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example" {
+		t.Fatalf("expected the origin to be allowed, got %q", got)
+	}
+}
+
+func TestCorsHandler_OriginPatterns(t *testing.T) {
+	router := NewRouter(nil)
+	router.GET("/items", okHandler)
+	h := corsTestApp(&config.CorsConfig{
+		Enabled:        true,
+		Origins:        []string{"https://*.example.com"},
+		OriginPatterns: []string{`https://pr-\d+\.preview\.example\.org`},
+	}).corsHandler(router)
+
+	cases := map[string]bool{
+		"https://a.example.com":             true,
+		"https://a.b.example.com":           true,
+		"https://example.com":               false,
+		"https://evil.com/.example.com":     false,
+		"https://pr-42.preview.example.org": true,
+		"https://pr-x.preview.example.org":  false,
+	}
+	for origin, want := range cases {
+		rec := corsRequest(h, http.MethodGet, "/items", origin, "")
+		if got := rec.Header().Get("Access-Control-Allow-Origin") == origin; got != want {
+			t.Errorf("%s: allowed=%v, want %v", origin, got, want)
+		}
+	}
+}
+
+func TestCorsHandler_RoutePolicies(t *testing.T) {
+	router := NewRouter(nil)
+	router.GET("/private", okHandler)
+	public := router.WithCors(&config.CorsConfig{Origins: []string{"*"}, AllowedMethods: []string{"GET", "PUT"}})
+	public.GET("/public", okHandler)
+	public.SubRouter("/v1").PUT("/items/{id}", okHandler)
+	router.Group(func(g *Router) {
+		g.WithCors(nil).GET("/internal", okHandler)
+	})
+
+	h := corsTestApp(&config.CorsConfig{Enabled: true, Origins: []string{"https://app.example"}}).corsHandler(router)
+	other := "https://other.example"
+
+	rec := corsRequest(h, http.MethodOptions, "/public", other, "GET")
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != other {
+		t.Errorf("expected the route policy to answer the preflight, got %d %v", rec.Code, rec.Header())
+	}
+	rec = corsRequest(h, http.MethodOptions, "/v1/items/7", other, "PUT")
+	if rec.Header().Get("Access-Control-Allow-Methods") != "PUT" {
+		t.Errorf("expected the sub-router to inherit the policy, got %v", rec.Header())
+	}
+	rec = corsRequest(h, http.MethodOptions, "/private", other, "GET")
+	if rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("expected the global policy to reject %s, got %v", other, rec.Header())
+	}
+	rec = corsRequest(h, http.MethodGet, "/internal", "https://app.example", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("expected CORS to be off for /internal, got %d %v", rec.Code, rec.Header())
+	}
+}
+
+func TestWithCors_PanicsOnInvalidPolicy(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for * with credentials")
+		}
+	}()
+	NewRouter(nil).WithCors(&config.CorsConfig{Origins: []string{"*"}, AllowCredentials: true})
+}
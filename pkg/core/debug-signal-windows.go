@@ -0,0 +1,11 @@
+//go:build windows
+
+package core
+
+import "os"
+
+// debugToggleSignals is empty on Windows, which has no SIGUSR1.
+var debugToggleSignals = []os.Signal{}
+
+// reloadSignals is empty on Windows; the config watcher still polls.
+var reloadSignals = []os.Signal{}
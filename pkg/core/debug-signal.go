@@ -0,0 +1,14 @@
+//go:build !windows
+
+package core
+
+import (
+	"os"
+	"syscall"
+)
+
+// debugToggleSignals flip the logger between its configured level and debug.
+var debugToggleSignals = []os.Signal{syscall.SIGUSR1}
+
+// reloadSignals make the config watcher reload immediately.
+var reloadSignals = []os.Signal{syscall.SIGHUP}
@@ -0,0 +1,790 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DIOption adjusts how a dependency is provided or resolved.
+type DIOption func(*diOptions)
+
+type diOptions struct {
+	name         string
+	eager        bool
+	lifetime     lifetime
+	noDispose    bool
+	closeTimeout time.Duration
+}
+
+// lifetime says how long a constructed dependency is kept.
+type lifetime int
+
+const (
+	singleton lifetime = iota // built once and shared by the app
+	transient                 // built on every resolve
+	scoped                    // built once per request
+)
+
+func (l lifetime) String() string {
+	switch l {
+	case transient:
+		return "transient"
+	case scoped:
+		return "request-scoped"
+	}
+	return "singleton"
+}
+
+// Named qualifies a dependency so several values of one type can coexist,
+// e.g. Provide(ctx, replica, Named("replica")). Resolve with the same name.
+func Named(name string) DIOption {
+	return func(o *diOptions) { o.name = name }
+}
+
+// Eager makes ProvideFunc build its dependency during ModuleManager.InitAll
+// rather than on first use, so constructor errors stop start-up.
+func Eager() DIOption {
+	return func(o *diOptions) { o.eager = true }
+}
+
+// Transient makes ProvideFunc call its constructor on every resolve instead
+// of keeping the first result. Results built in a request are disposed of
+// with the request; others belong to the caller.
+func Transient() DIOption {
+	return func(o *diOptions) { o.lifetime = transient }
+}
+
+// RequestScoped makes ProvideFunc build its dependency once per request. It
+// can only be resolved from a RequestContext, and results implementing
+// io.Closer or Shutdown(ctx) error are closed when the request ends, newest
+// first.
+func RequestScoped() DIOption {
+	return func(o *diOptions) { o.lifetime = scoped }
+}
+
+// NoDispose keeps a dependency open on shutdown, for values owned by
+// someone else. The app's logger is provided this way.
+func NoDispose() DIOption {
+	return func(o *diOptions) { o.noDispose = true }
+}
+
+// CloseTimeout overrides server.disposeTimeout for one dependency.
+func CloseTimeout(d time.Duration) DIOption {
+	return func(o *diOptions) { o.closeTimeout = d }
+}
+
+func newDIOptions(opts []DIOption) diOptions {
+	var o diOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// diKey identifies a dependency by its static type and qualifier.
+type diKey struct {
+	typ  reflect.Type
+	name string
+}
+
+func (k diKey) String() string {
+	if k.name == "" {
+		return k.typ.String()
+	}
+	return fmt.Sprintf("%s %q", k.typ, k.name)
+}
+
+// MissingDependencyError is returned by Resolve when nothing was provided
+// for a type. Path is the chain of constructors that needed it, if any.
+// Similar lists registered dependencies that were probably meant: the same
+// type under another name, a pointer or value of it, a type with the same
+// name from another package, or an implementation of the requested
+// interface.
+type MissingDependencyError struct {
+	Type    reflect.Type
+	Name    string
+	Path    []string
+	Similar []string
+}
+
+func (e *MissingDependencyError) Error() string {
+	key := diKey{e.Type, e.Name}.String()
+	msg := "core: no " + key + " provided"
+	if len(e.Path) > 0 {
+		msg = "core: " + strings.Join(append(e.Path, key), " -> ") + ": no " + key + " provided"
+	}
+	if len(e.Similar) > 0 {
+		msg += "; registered: " + strings.Join(e.Similar, ", ")
+	}
+	return msg
+}
+
+// CycleError reports constructors that depend on each other. Path starts
+// and ends with the same dependency.
+type CycleError struct {
+	Path []string
+}
+
+func (e *CycleError) Error() string {
+	return "core: dependency cycle: " + strings.Join(e.Path, " -> ")
+}
+
+// ConstructorError wraps an error returned by a constructor. Path leads from
+// the dependency that was resolved to the one that failed.
+type ConstructorError struct {
+	Path []string
+	Err  error
+}
+
+func (e *ConstructorError) Error() string {
+	return "core: constructing " + strings.Join(e.Path, " -> ") + ": " + e.Err.Error()
+}
+
+func (e *ConstructorError) Unwrap() error {
+	return e.Err
+}
+
+// ScopeError reports a dependency used outside its lifetime: a
+// request-scoped one resolved without a request, or captured by a singleton.
+// Path leads from the dependency that was resolved or registered to the
+// request-scoped one.
+type ScopeError struct {
+	Path []string
+	Msg  string
+}
+
+func (e *ScopeError) Error() string {
+	return "core: " + strings.Join(e.Path, " -> ") + ": " + e.Msg
+}
+
+// provider produces one dependency: a value, or a constructor whose result
+// is built according to its lifetime. Singletons are kept in the provider.
+type provider struct {
+	key      diKey
+	fn       reflect.Value // constructor; invalid for plain values
+	params   []diKey
+	eager    bool
+	lifetime lifetime
+
+	noDispose    bool
+	closeTimeout time.Duration
+
+	mu    sync.Mutex
+	built bool
+	value any
+}
+
+// diScope holds the request-scoped dependencies of one request.
+type diScope struct {
+	mu      sync.Mutex
+	entries map[diKey]*scopedEntry
+	created []disposal // in creation order, for close
+}
+
+type scopedEntry struct {
+	mu    sync.Mutex
+	built bool
+	value any
+}
+
+func (s *diScope) entry(key diKey) *scopedEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.entries == nil {
+		s.entries = make(map[diKey]*scopedEntry)
+	}
+	e := s.entries[key]
+	if e == nil {
+		e = &scopedEntry{}
+		s.entries[key] = e
+	}
+	return e
+}
+
+func (s *diScope) track(p *provider, v any) {
+	if p.noDispose || !disposable(v) {
+		return
+	}
+	s.mu.Lock()
+	s.created = append(s.created, disposal{key: p.key, value: v, timeout: p.closeTimeout})
+	s.mu.Unlock()
+}
+
+// close disposes of the dependencies built in the scope, newest first, and
+// forgets them. Each gets timeout unless it has its own CloseTimeout.
+func (s *diScope) close(timeout time.Duration) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	created := s.created
+	s.created, s.entries = nil, nil
+	s.mu.Unlock()
+
+	var errs []error
+	for i := len(created) - 1; i >= 0; i-- {
+		if err := created[i].close(timeout); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// shutdowner is a resource closed with a deadline, like *http.Server.
+type shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+func disposable(v any) bool {
+	switch v.(type) {
+	case shutdowner, io.Closer:
+		return true
+	}
+	return false
+}
+
+// dispose shuts v down, preferring Shutdown over Close. It returns when
+// ctx is done even if v is still closing.
+func dispose(ctx context.Context, v any) error {
+	done := make(chan error, 1)
+	go func() {
+		switch r := v.(type) {
+		case shutdowner:
+			done <- r.Shutdown(ctx)
+		case io.Closer:
+			done <- r.Close()
+		default:
+			done <- nil
+		}
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Injector is what dependencies are resolved from: a *Context, or a
+// *RequestContext, which can also resolve request-scoped dependencies.
+type Injector interface {
+	injector() (*container, *diScope)
+}
+
+func (ctx *Context) injector() (*container, *diScope) {
+	return ctx.container(), nil
+}
+
+func (c *RequestContext) injector() (*container, *diScope) {
+	if c.App == nil {
+		return newContainer(), c.scope
+	}
+	return c.App.container(), c.scope
+}
+
+// container holds the typed dependencies of a Context.
+type container struct {
+	mu        sync.RWMutex
+	providers map[diKey]*provider
+	started   bool       // start has checked the graph; add checks for cycles
+	created   []disposal // app-lifetime resources in creation order
+}
+
+// disposal is a resource to close on shutdown or at the end of a request.
+type disposal struct {
+	key     diKey
+	value   any
+	timeout time.Duration
+}
+
+// defaultCloseTimeout limits closing a resource when neither CloseTimeout
+// nor a positive server.disposeTimeout applies.
+const defaultCloseTimeout = 5 * time.Second
+
+// close disposes of d, giving it its own CloseTimeout, else timeout, else
+// defaultCloseTimeout.
+func (d disposal) close(timeout time.Duration) error {
+	if d.timeout > 0 {
+		timeout = d.timeout
+	}
+	if timeout <= 0 {
+		timeout = defaultCloseTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := dispose(ctx, d.value); err != nil {
+		return fmt.Errorf("core: closing %s: %w", d.key, err)
+	}
+	return nil
+}
+
+// track records v, built or provided for p, to be disposed of on shutdown.
+// Values seen before are recorded once, at their first position.
+func (c *container) track(p *provider, v any) {
+	if p.noDispose || !disposable(v) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if reflect.TypeOf(v).Comparable() {
+		for _, d := range c.created {
+			if reflect.TypeOf(d.value) == reflect.TypeOf(v) && d.value == v {
+				return
+			}
+		}
+	}
+	c.created = append(c.created, disposal{key: p.key, value: v, timeout: p.closeTimeout})
+}
+
+// dispose closes the tracked resources, newest first, giving each timeout
+// unless it has its own CloseTimeout. Every resource is attempted; the
+// errors are joined.
+func (c *container) dispose(timeout time.Duration) error {
+	c.mu.Lock()
+	created := c.created
+	c.created = nil
+	c.mu.Unlock()
+
+	var errs []error
+	for i := len(created) - 1; i >= 0; i-- {
+		if err := created[i].close(timeout); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func newContainer() *container {
+	return &container{providers: make(map[diKey]*provider)}
+}
+
+// container returns the DI container of ctx, creating it on first use.
+func (ctx *Context) container() *container {
+	ctx.mu.Lock()
+	defer ctx.mu.Unlock()
+	if ctx.di == nil {
+		ctx.di = newContainer()
+	}
+	return ctx.di
+}
+
+// add registers p. It panics, keeping the previous provider, if a singleton
+// would then capture a request-scoped dependency, or if the container has
+// started and p closes a cycle, which would otherwise deadlock on first use.
+func (c *container) add(p *provider) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	old, replaced := c.providers[p.key]
+	c.providers[p.key] = p
+	err := c.captive()
+	if err == nil && c.started {
+		err = c.cycle(p.key)
+	}
+	if err != nil {
+		if replaced {
+			c.providers[p.key] = old
+		} else {
+			delete(c.providers, p.key)
+		}
+		panic(err)
+	}
+}
+
+// captive returns a ScopeError if a singleton constructor depends on a
+// request-scoped one, directly or through transient ones. c.mu must be held.
+func (c *container) captive() error {
+	keys := make([]diKey, 0, len(c.providers))
+	for k, p := range c.providers {
+		if p.fn.IsValid() && p.lifetime == singleton {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+	var walk func(path []diKey) []diKey
+	walk = func(path []diKey) []diKey {
+		for _, param := range c.providers[path[len(path)-1]].params {
+			dep, ok := c.providers[param]
+			if !ok || indexKey(path, param) >= 0 {
+				continue
+			}
+			switch dep.lifetime {
+			case scoped:
+				return append(path, param)
+			case transient:
+				if found := walk(append(path, param)); found != nil {
+					return found
+				}
+			}
+		}
+		return nil
+	}
+	for _, k := range keys {
+		if path := walk([]diKey{k}); path != nil {
+			return &ScopeError{Path: keyStrings(path), Msg: "singleton " + k.String() + " cannot depend on request-scoped " + path[len(path)-1].String()}
+		}
+	}
+	return nil
+}
+
+// cycle returns a CycleError if a constructor below key depends on itself.
+// c.mu must be held.
+func (c *container) cycle(key diKey) error {
+	done := make(map[diKey]bool)
+	var walk func(path []diKey) error
+	walk = func(path []diKey) error {
+		last := path[len(path)-1]
+		p := c.providers[last]
+		if p == nil || done[last] {
+			return nil
+		}
+		for _, param := range p.params {
+			if i := indexKey(path, param); i >= 0 {
+				return &CycleError{Path: keyStrings(append(path[i:], param))}
+			}
+			if err := walk(append(path, param)); err != nil {
+				return err
+			}
+		}
+		done[last] = true
+		return nil
+	}
+	return walk([]diKey{key})
+}
+
+func (c *container) lookup(key diKey) *provider {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.providers[key]
+}
+
+// typeOf returns the static type T, which is an interface type when T is
+// one, unlike reflect.TypeOf on a value.
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// Provide registers value as the T of ctx, replacing any earlier one with
+// the same qualifier. Provide[Store](ctx, pg) registers pg as a Store;
+// resolving its concrete type then fails.
+//
+// Values implementing io.Closer or Shutdown(ctx) error are closed by
+// Lilium.Start on shutdown, newest first, unless NoDispose is given.
+func Provide[T any](ctx *Context, value T, opts ...DIOption) {
+	o := newDIOptions(opts)
+	p := &provider{key: diKey{typeOf[T](), o.name}, built: true, value: value, noDispose: o.noDispose, closeTimeout: o.closeTimeout}
+	c := ctx.container()
+	c.add(p)
+	c.track(p, value)
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// ProvideFunc registers a constructor for its first result type, e.g.
+//
+//	ctx.ProvideFunc(func(cfg *config.LiliumConfig, log *logger.Logger) (*Repo, error) { ... })
+//
+// Parameters are resolved from the container when the result is first
+// needed, and the result is kept. With Eager it is built during
+// ModuleManager.InitAll instead, which also checks every constructor for
+// missing dependencies and cycles. Transient and RequestScoped change how
+// long the result is kept. Results implementing io.Closer or Shutdown(ctx)
+// error are disposed of like provided values, or at the end of the request
+// for those built in a request scope; transient results built outside a
+// request are not. It panics if constructor is not a function
+// returning T or (T, error), if Eager is combined with another lifetime, if
+// a singleton would depend on a request-scoped dependency, or if it is called
+// after InitAll with a constructor that closes a dependency cycle.
+func (ctx *Context) ProvideFunc(constructor any, opts ...DIOption) {
+	fn := reflect.ValueOf(constructor)
+	t := fn.Type()
+	if t.Kind() != reflect.Func || t.IsVariadic() || t.NumOut() < 1 || t.NumOut() > 2 ||
+		t.NumOut() == 2 && t.Out(1) != errorType {
+		panic(fmt.Sprintf("core: ProvideFunc needs a func returning T or (T, error), got %s", t))
+	}
+
+	o := newDIOptions(opts)
+	if o.eager && o.lifetime != singleton {
+		panic(fmt.Sprintf("core: ProvideFunc: Eager only applies to singletons, not %s %s", o.lifetime, t.Out(0)))
+	}
+	p := &provider{key: diKey{t.Out(0), o.name}, fn: fn, eager: o.eager, lifetime: o.lifetime,
+		noDispose: o.noDispose, closeTimeout: o.closeTimeout}
+	for i := 0; i < t.NumIn(); i++ {
+		p.params = append(p.params, diKey{typ: t.In(i)})
+	}
+	ctx.container().add(p)
+}
+
+// Resolve returns the T provided to the app of in, building it if it comes
+// from a constructor. Request-scoped dependencies need a *RequestContext.
+// When nothing was provided the error is a *MissingDependencyError.
+func Resolve[T any](in Injector, opts ...DIOption) (T, error) {
+	var zero T
+	c, sc := in.injector()
+	v, err := c.resolve(diKey{typeOf[T](), newDIOptions(opts).name}, nil, sc)
+	if err != nil || v == nil {
+		return zero, err
+	}
+	return v.(T), nil
+}
+
+// MustResolve is like Resolve but panics when T was not provided. Use it
+// during start-up, where a missing dependency is a programming error.
+func MustResolve[T any](in Injector, opts ...DIOption) T {
+	v, err := Resolve[T](in, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// resolve returns the value for key; path holds the dependencies being
+// built that led here and sc the request scope, if any.
+func (c *container) resolve(key diKey, path []diKey, sc *diScope) (any, error) {
+	if i := indexKey(path, key); i >= 0 {
+		return nil, &CycleError{Path: keyStrings(append(path[i:], key))}
+	}
+	p := c.lookup(key)
+	if p == nil {
+		return nil, c.missing(key, path)
+	}
+
+	switch p.lifetime {
+	case transient:
+		v, err := c.build(p, path, sc)
+		if err != nil {
+			return nil, err
+		}
+		// Outside a request the caller owns the value; tracking it would
+		// keep every instance until shutdown.
+		if sc != nil {
+			sc.track(p, v)
+		}
+		return v, nil
+	case scoped:
+		if sc == nil {
+			return nil, &ScopeError{Path: keyStrings(append(path, key)), Msg: "request-scoped; resolve it from a RequestContext"}
+		}
+		e := sc.entry(key)
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		if !e.built {
+			v, err := c.build(p, path, sc)
+			if err != nil {
+				return nil, err
+			}
+			e.value, e.built = v, true
+			sc.track(p, v)
+		}
+		return e.value, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.built {
+		// Singletons never see the request scope.
+		v, err := c.build(p, path, nil)
+		if err != nil {
+			return nil, err
+		}
+		p.value, p.built = v, true
+		c.track(p, v)
+	}
+	return p.value, nil
+}
+
+// build calls the constructor of p with its resolved parameters.
+func (c *container) build(p *provider, path []diKey, sc *diScope) (any, error) {
+	path = append(path, p.key)
+	args := make([]reflect.Value, len(p.params))
+	for i, param := range p.params {
+		v, err := c.resolve(param, path, sc)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = reflect.New(param.typ).Elem()
+		if v != nil {
+			args[i].Set(reflect.ValueOf(v))
+		}
+	}
+
+	out := p.fn.Call(args)
+	if len(out) == 2 && !out[1].IsNil() {
+		err := out[1].Interface().(error)
+		var ce *ConstructorError
+		if errors.As(err, &ce) {
+			return nil, err
+		}
+		return nil, &ConstructorError{Path: keyStrings(path), Err: err}
+	}
+	return out[0].Interface(), nil
+}
+
+func (c *container) missing(key diKey, path []diKey) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return &MissingDependencyError{Type: key.typ, Name: key.name, Path: keyStrings(path), Similar: c.similar(key)}
+}
+
+// start checks that every constructor can be satisfied, then builds the
+// eager ones. All missing dependencies and cycles are reported at once.
+func (c *container) start() error {
+	c.mu.RLock()
+	providers := make([]*provider, 0, len(c.providers))
+	for _, p := range c.providers {
+		providers = append(providers, p)
+	}
+	c.mu.RUnlock()
+	sort.Slice(providers, func(i, j int) bool { return providers[i].key.String() < providers[j].key.String() })
+
+	var errs []error
+	seen := make(map[string]bool)
+	done := make(map[diKey]bool)
+	for _, p := range providers {
+		c.check(p.key, nil, done, func(err error) {
+			if !seen[err.Error()] {
+				seen[err.Error()] = true
+				errs = append(errs, err)
+			}
+		})
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	c.mu.Lock()
+	c.started = true
+	c.mu.Unlock()
+
+	for _, p := range providers {
+		if p.eager {
+			if _, err := c.resolve(p.key, nil, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// check walks the constructor graph below key without building anything.
+func (c *container) check(key diKey, path []diKey, done map[diKey]bool, report func(error)) {
+	if i := indexKey(path, key); i >= 0 {
+		report(&CycleError{Path: keyStrings(append(path[i:], key))})
+		return
+	}
+	if done[key] {
+		return
+	}
+	p := c.lookup(key)
+	if p == nil {
+		report(c.missing(key, path))
+		return
+	}
+	for _, param := range p.params {
+		c.check(param, append(path, key), done, report)
+	}
+	done[key] = true
+}
+
+// DependencyGraph describes the container: every dependency with how it is
+// provided, and below constructors the dependencies they take.
+//
+//	*main.Repo (constructor, lazy)
+//	  <- *config.LiliumConfig
+//	  <- *logger.Logger
+func (ctx *Context) DependencyGraph() string {
+	c := ctx.container()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	keys := make([]diKey, 0, len(c.providers))
+	for k := range c.providers {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+	var b strings.Builder
+	for _, k := range keys {
+		p := c.providers[k]
+		if !p.fn.IsValid() {
+			fmt.Fprintf(&b, "%s (value)\n", k)
+			continue
+		}
+		p.mu.Lock()
+		state := "lazy"
+		switch {
+		case p.lifetime != singleton:
+			state = p.lifetime.String()
+		case p.built:
+			state = "built"
+		case p.eager:
+			state = "eager"
+		}
+		p.mu.Unlock()
+		fmt.Fprintf(&b, "%s (constructor, %s)\n", k, state)
+		for _, param := range p.params {
+			if _, ok := c.providers[param]; ok {
+				fmt.Fprintf(&b, "  <- %s\n", param)
+			} else {
+				fmt.Fprintf(&b, "  <- %s (missing)\n", param)
+			}
+		}
+	}
+	return b.String()
+}
+
+func indexKey(path []diKey, key diKey) int {
+	for i, k := range path {
+		if k == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func keyStrings(keys []diKey) []string {
+	out := make([]string, len(keys))
+	for i, k := range keys {
+		out[i] = k.String()
+	}
+	return out
+}
+
+// similar lists the registered keys that look like key, sorted.
+func (c *container) similar(key diKey) []string {
+	var out []string
+	for k := range c.providers {
+		if similarTypes(key.typ, k.typ) {
+			out = append(out, k.String())
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func similarTypes(want, have reflect.Type) bool {
+	switch {
+	case want == have:
+		return true
+	case have.Kind() == reflect.Pointer && have.Elem() == want,
+		want.Kind() == reflect.Pointer && want.Elem() == have:
+		return true
+	case want.Kind() == reflect.Interface && have.Implements(want):
+		return true
+	}
+	return baseTypeName(want) != "" && baseTypeName(want) == baseTypeName(have)
+}
+
+// baseTypeName returns the name of t without pointers or package.
+func baseTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Name()
+}
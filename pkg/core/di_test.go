@@ -0,0 +1,374 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spyder01/lilium-go/pkg/config"
+	"github.com/spyder01/lilium-go/pkg/logger"
+)
+
+type testStore interface{ Get(key string) string }
+
+type testMemStore struct{ data map[string]string }
+
+func (m *testMemStore) Get(key string) string { return m.data[key] }
+
+type testDB struct{ dsn string }
+
+func TestProvideResolve_ByType(t *testing.T) {
+	ctx := newTestContext()
+	primary := &testDB{dsn: "primary"}
+	Provide(ctx, primary)
+	Provide(ctx, &testDB{dsn: "replica"}, Named("replica"))
+	Provide[testStore](ctx, &testMemStore{data: map[string]string{"k": "v"}})
+
+	db, err := Resolve[*testDB](ctx)
+	if err != nil || db != primary {
+		t.Fatalf("expected the primary db, got %v, %v", db, err)
+	}
+	if replica := MustResolve[*testDB](ctx, Named("replica")); replica.dsn != "replica" {
+		t.Fatalf("expected the replica, got %+v", replica)
+	}
+	if store := MustResolve[testStore](ctx); store.Get("k") != "v" {
+		t.Fatal("expected the store to resolve by its interface type")
+	}
+}
+
+func TestResolve_MissingListsSimilar(t *testing.T) {
+	ctx := newTestContext()
+	Provide(ctx, &testDB{}, Named("replica"))
+	Provide(ctx, &testMemStore{})
+
+	_, err := Resolve[*testDB](ctx)
+	var missing *MissingDependencyError
+	if !errors.As(err, &missing) {
+		t.Fatalf("expected a MissingDependencyError, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `no *core.testDB provided; registered: *core.testDB "replica"`) {
+		t.Errorf("unexpected message %q", err)
+	}
+
+	_, err = Resolve[testDB](ctx)
+	if err == nil || !strings.Contains(err.Error(), `*core.testDB "replica"`) {
+		t.Errorf("expected the pointer type to be suggested, got %v", err)
+	}
+
+	_, err = Resolve[testStore](ctx)
+	if err == nil || !strings.Contains(err.Error(), "registered: *core.testMemStore") {
+		t.Errorf("expected the implementation to be suggested, got %v", err)
+	}
+}
+
+func TestMustResolve_Panics(t *testing.T) {
+	defer func() {
+		if _, ok := recover().(*MissingDependencyError); !ok {
+			t.Fatal("expected MustResolve to panic with a MissingDependencyError")
+		}
+	}()
+	MustResolve[*testDB](newTestContext())
+}
+
+type testRepo struct{ db *testDB }
+
+type testService struct{ repo *testRepo }
+
+func TestProvideFunc_LazyWithParams(t *testing.T) {
+	ctx := newTestContext()
+	Provide(ctx, &testDB{dsn: "primary"})
+	calls := 0
+	ctx.ProvideFunc(func(db *testDB) (*testRepo, error) {
+		calls++
+		return &testRepo{db: db}, nil
+	})
+	ctx.ProvideFunc(func(r *testRepo) *testService { return &testService{repo: r} })
+
+	if err := ctx.container().start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if calls != 0 {
+		t.Fatal("expected lazy constructors to wait for first use")
+	}
+
+	svc := MustResolve[*testService](ctx)
+	if svc.repo.db.dsn != "primary" {
+		t.Fatalf("expected the graph to be wired, got %+v", svc.repo)
+	}
+	if MustResolve[*testRepo](ctx) != svc.repo || calls != 1 {
+		t.Fatalf("expected one shared repo, constructor ran %d times", calls)
+	}
+}
+
+func TestProvideFunc_EagerErrorStopsStart(t *testing.T) {
+	ctx := newTestContext()
+	boom := errors.New("connection refused")
+	ctx.ProvideFunc(func() (*testDB, error) { return nil, boom })
+	ctx.ProvideFunc(func(db *testDB) *testRepo { return &testRepo{db: db} }, Eager())
+
+	err := ctx.container().start()
+	if !errors.Is(err, boom) {
+		t.Fatalf("expected the constructor error, got %v", err)
+	}
+	if want := "core: constructing *core.testRepo -> *core.testDB: connection refused"; err.Error() != want {
+		t.Fatalf("expected %q, got %q", want, err)
+	}
+}
+
+func TestContainerStart_CyclesAndMissing(t *testing.T) {
+	ctx := newTestContext()
+	ctx.ProvideFunc(func(s *testService) *testRepo { return nil })
+	ctx.ProvideFunc(func(r *testRepo) *testService { return nil })
+	ctx.ProvideFunc(func(db *testDB) testStore { return nil })
+
+	err := ctx.container().start()
+	if err == nil {
+		t.Fatal("expected start to fail")
+	}
+	for _, want := range []string{
+		"core: dependency cycle: *core.testRepo -> *core.testService -> *core.testRepo",
+		"core: core.testStore -> *core.testDB: no *core.testDB provided",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in:\n%v", want, err)
+		}
+	}
+
+	if _, err := Resolve[*testService](ctx); !strings.Contains(fmt.Sprint(err), "dependency cycle") {
+		t.Errorf("expected Resolve to report the cycle, got %v", err)
+	}
+}
+
+func TestDependencyGraph(t *testing.T) {
+	ctx := newTestContext()
+	Provide(ctx, &testDB{})
+	ctx.ProvideFunc(func(db *testDB, s testStore) *testRepo { return nil }, Eager())
+
+	want := `*core.testDB (value)
+*core.testRepo (constructor, eager)
+  <- *core.testDB
+  <- core.testStore (missing)
+`
+	if got := ctx.DependencyGraph(); got != want {
+		t.Fatalf("unexpected graph:\n%s", got)
+	}
+}
+
+func TestProvideFunc_PanicsOnBadSignature(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+	newTestContext().ProvideFunc(func() (*testDB, string) { return nil, "" })
+}
+
+func TestInitAll_BuildsEagerDependencies(t *testing.T) {
+	cfg, err := config.LoadReader(strings.NewReader("name: di-test\n"), config.LoadOptions{NoEnv: true})
+	if err != nil {
+		t.Fatalf("LoadReader: %v", err)
+	}
+	app := New(cfg, context.Background())
+
+	var gotName string
+	app.Context.ProvideFunc(func(cfg *config.LiliumConfig, log *logger.Logger, ctx *Context) (*testRepo, error) {
+		gotName = cfg.Name
+		if log == nil || ctx != app.Context {
+			return nil, errors.New("built-ins not provided")
+		}
+		return &testRepo{}, nil
+	}, Eager())
+	if err := app.moduleManager.InitAll(); err != nil {
+		t.Fatalf("InitAll: %v", err)
+	}
+	if gotName != "di-test" {
+		t.Fatalf("expected the eager constructor to run with the config, got %q", gotName)
+	}
+
+	app.Context.ProvideFunc(func(db *testDB) *testService { return nil })
+	if err := app.moduleManager.InitAll(); err == nil || !strings.Contains(err.Error(), "no *core.testDB provided") {
+		t.Fatalf("expected InitAll to report the missing provider, got %v", err)
+	}
+}
+
+type testTx struct {
+	id     int
+	closed *[]int
+}
+
+func (tx *testTx) Close() error {
+	*tx.closed = append(*tx.closed, tx.id)
+	return nil
+}
+
+func TestLifetimes_TransientAndRequestScoped(t *testing.T) {
+	ctx := newTestContext()
+	var closed []int
+	txs := 0
+	ctx.ProvideFunc(func() *testTx {
+		txs++
+		return &testTx{id: txs, closed: &closed}
+	}, RequestScoped())
+	ctx.ProvideFunc(func(tx *testTx) *testRepo { return &testRepo{db: &testDB{dsn: fmt.Sprint(tx.id)}} }, Transient())
+
+	if _, err := Resolve[*testTx](ctx); !strings.Contains(fmt.Sprint(err), "request-scoped; resolve it from a RequestContext") {
+		t.Fatalf("expected a scope error outside a request, got %v", err)
+	}
+
+	router := NewRouter(ctx)
+	router.Use(func(next HandlerFunc) HandlerFunc {
+		return func(rc *RequestContext) error {
+			MustResolve[*testTx](rc)
+			return next(rc)
+		}
+	})
+	router.GET("/", func(rc *RequestContext) error {
+		a, b := MustResolve[*testRepo](rc), MustResolve[*testRepo](rc)
+		if a == b {
+			t.Error("expected a new transient on every resolve")
+		}
+		if a.db.dsn != b.db.dsn {
+			t.Error("expected one transaction per request")
+		}
+		return nil
+	})
+	for i := 0; i < 2; i++ {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+	if fmt.Sprint(closed) != "[1 2]" {
+		t.Fatalf("expected each request's transaction to be closed, got %v", closed)
+	}
+}
+
+func TestProvideFunc_SingletonCannotCaptureScoped(t *testing.T) {
+	ctx := newTestContext()
+	ctx.ProvideFunc(func(r *testRepo) *testService { return nil })
+	ctx.ProvideFunc(func(db *testDB) *testRepo { return nil }, Transient())
+
+	defer func() {
+		err, ok := recover().(*ScopeError)
+		if !ok {
+			t.Fatalf("expected a ScopeError panic, got %v", err)
+		}
+		want := "core: *core.testService -> *core.testRepo -> *core.testDB: singleton *core.testService cannot depend on request-scoped *core.testDB"
+		if err.Error() != want {
+			t.Fatalf("expected %q, got %q", want, err)
+		}
+		var missing *MissingDependencyError
+		if _, err := Resolve[*testDB](ctx); !errors.As(err, &missing) {
+			t.Fatal("expected the offending provider not to be registered")
+		}
+	}()
+	ctx.ProvideFunc(func() *testDB { return nil }, RequestScoped())
+}
+
+type testCloser struct {
+	name string
+	log  *[]string
+	err  error
+}
+
+func (c *testCloser) Close() error {
+	*c.log = append(*c.log, c.name)
+	return c.err
+}
+
+type testServer struct{ block chan struct{} }
+
+func (s *testServer) Shutdown(ctx context.Context) error {
+	select {
+	case <-s.block:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestContainerDispose_ReverseOrderAndErrors(t *testing.T) {
+	ctx := newTestContext()
+	var log []string
+	pool := &testCloser{name: "pool", log: &log}
+	Provide(ctx, pool)
+	Provide[io.Closer](ctx, pool) // same value under a second type
+	Provide(ctx, &testCloser{name: "shared", log: &log}, Named("shared"), NoDispose())
+	ctx.ProvideFunc(func(p *testCloser) *testRepo { return &testRepo{} })
+	ctx.ProvideFunc(func() (*testServer, error) { return &testServer{block: make(chan struct{})}, nil }, CloseTimeout(10*time.Millisecond))
+	ctx.ProvideFunc(func(*testServer) io.ReadCloser {
+		return &testReadCloser{testCloser{name: "client", log: &log, err: errors.New("broken pipe")}}
+	})
+	MustResolve[io.ReadCloser](ctx)
+	MustResolve[*testRepo](ctx)
+
+	err := ctx.container().dispose(time.Second)
+	if fmt.Sprint(log) != "[client pool]" {
+		t.Fatalf("expected newest first, each once, got %v", log)
+	}
+	for _, want := range []string{
+		"core: closing io.ReadCloser: broken pipe",
+		"core: closing *core.testServer: context deadline exceeded",
+	} {
+		if !strings.Contains(fmt.Sprint(err), want) {
+			t.Errorf("expected %q in:\n%v", want, err)
+		}
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("expected the timeout to be wrapped")
+	}
+	if err := ctx.container().dispose(time.Second); err != nil || len(log) != 2 {
+		t.Fatalf("expected a second dispose to do nothing, got %v %v", err, log)
+	}
+}
+
+type testReadCloser struct{ testCloser }
+
+func (*testReadCloser) Read([]byte) (int, error) { return 0, io.EOF }
+
+func TestProvideFunc_CycleAfterStartPanics(t *testing.T) {
+	ctx := newTestContext()
+	ctx.ProvideFunc(func(s *testService) *testRepo { return &testRepo{} })
+	ctx.ProvideFunc(func() *testService { return &testService{} })
+	if err := ctx.container().start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	defer func() {
+		err, ok := recover().(*CycleError)
+		if !ok {
+			t.Fatalf("expected a CycleError panic, got %v", err)
+		}
+		if want := "core: dependency cycle: *core.testService -> *core.testRepo -> *core.testService"; err.Error() != want {
+			t.Fatalf("expected %q, got %q", want, err)
+		}
+		if _, err := Resolve[*testService](ctx); err != nil {
+			t.Fatalf("expected the previous constructor to be kept, got %v", err)
+		}
+	}()
+	ctx.ProvideFunc(func(r *testRepo) *testService { return nil })
+}
+
+func TestDispose_TransientsAndScopeTimeout(t *testing.T) {
+	ctx := newTestContext()
+	var log []string
+	ctx.ProvideFunc(func() *testCloser { return &testCloser{name: "conn", log: &log} }, Transient())
+	ctx.ProvideFunc(func() *testServer { return &testServer{block: make(chan struct{})} }, RequestScoped())
+	for i := 0; i < 3; i++ {
+		MustResolve[*testCloser](ctx)
+	}
+	if err := ctx.container().dispose(time.Second); err != nil || len(log) != 0 {
+		t.Fatalf("expected transients built outside a request to be left to the caller, got %v %v", err, log)
+	}
+
+	rc := NewRequestContext(ctx, httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	MustResolve[*testServer](rc)
+	MustResolve[*testCloser](rc)
+	err := rc.scope.close(10 * time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) || fmt.Sprint(log) != "[conn]" {
+		t.Fatalf("expected the scope to close within the timeout, got %v %v", err, log)
+	}
+}
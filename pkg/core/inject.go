@@ -0,0 +1,152 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/spyder01/lilium-go/pkg/config"
+)
+
+// Inject fills the tagged fields of the struct target points to:
+//
+//	type AuthHandler struct {
+//	    DB       *sql.DB       `inject:""`
+//	    Replica  *sql.DB       `inject:"replica"`
+//	    TokenTTL time.Duration `config:"auth.tokenTTL"`
+//	    Audience string        `config:"auth.audience,optional"`
+//	}
+//
+// inject fields are resolved by type, and by name when the tag has one.
+// config fields are decoded at a dotted path, which may point into Extras,
+// from the app's current config; they are not updated by later reloads.
+// With ",optional" a missing value leaves the field as it is. Untagged embedded structs are filled too. Every field is
+// attempted and the errors are joined; missing dependencies keep their
+// *MissingDependencyError.
+func Inject(in Injector, target any) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("core: Inject needs a pointer to a struct, got %T", target)
+	}
+	inj := &injection{in: in}
+	inj.fill(v.Elem(), v.Elem().Type().String())
+	return errors.Join(inj.errs...)
+}
+
+// MustInject is like Inject but panics on error.
+func MustInject(in Injector, target any) {
+	if err := Inject(in, target); err != nil {
+		panic(err)
+	}
+}
+
+type injection struct {
+	in   Injector
+	cfg  *config.LiliumConfig
+	errs []error
+}
+
+func (inj *injection) fill(v reflect.Value, typeName string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, hasInject := f.Tag.Lookup("inject")
+		path, hasConfig := f.Tag.Lookup("config")
+
+		if !hasInject && !hasConfig {
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				inj.fill(v.Field(i), typeName)
+			}
+			continue
+		}
+
+		field := typeName + "." + f.Name
+		if !f.IsExported() {
+			inj.errs = append(inj.errs, fmt.Errorf("core: injecting %s: field is unexported", field))
+			continue
+		}
+		if hasInject && hasConfig {
+			inj.errs = append(inj.errs, fmt.Errorf("core: injecting %s: field has both inject and config tags", field))
+			continue
+		}
+
+		var err error
+		if hasInject {
+			err = inj.dependency(v.Field(i), diKey{f.Type, name})
+		} else {
+			err = inj.config(v.Field(i), path)
+		}
+		if err != nil {
+			inj.errs = append(inj.errs, fmt.Errorf("core: injecting %s: %w", field, err))
+		}
+	}
+}
+
+func (inj *injection) dependency(field reflect.Value, key diKey) error {
+	c, sc := inj.in.injector()
+	dep, err := c.resolve(key, nil, sc)
+	if err != nil {
+		return err
+	}
+	if dep != nil {
+		field.Set(reflect.ValueOf(dep))
+	}
+	return nil
+}
+
+func (inj *injection) config(field reflect.Value, tag string) error {
+	path, opt, _ := strings.Cut(tag, ",")
+	if inj.cfg == nil {
+		if live, err := Resolve[LiveConfig](inj.in); err == nil {
+			inj.cfg = live()
+		} else {
+			cfg, err := Resolve[*config.LiliumConfig](inj.in)
+			if err != nil {
+				return err
+			}
+			inj.cfg = cfg
+		}
+	}
+
+	out := reflect.New(field.Type())
+	if err := inj.cfg.DecodePath(path, out.Interface()); err != nil {
+		if opt == "optional" && errors.Is(err, config.ErrExtraNotFound) {
+			return nil
+		}
+		return err
+	}
+	field.Set(out.Elem())
+	return nil
+}
+
+// RouteHandler is a handler struct that registers its own routes, usually
+// method values:
+//
+//	func (h *AuthHandler) Routes(r *core.Router) {
+//	    r.POST("/login", h.Login)
+//	}
+type RouteHandler interface {
+	Routes(r *Router)
+}
+
+// Handle injects the tagged fields of each handler struct from the router's
+// app, then lets it register its routes on r. It panics if injection fails,
+// since routes are set up during start-up.
+func (r *Router) Handle(handlers ...RouteHandler) {
+	for _, h := range handlers {
+		if injectable(h) {
+			if r.app == nil {
+				panic("core: Router.Handle needs a router created with an app context")
+			}
+			MustInject(r.app, h)
+		}
+		h.Routes(r)
+	}
+}
+
+// injectable reports whether v is a pointer to a struct.
+func injectable(v any) bool {
+	t := reflect.TypeOf(v)
+	return t != nil && t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct
+}
@@ -0,0 +1,155 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spyder01/lilium-go/pkg/config"
+)
+
+func injectTestApp(t *testing.T, yaml string) *Lilium {
+	t.Helper()
+	cfg, err := config.LoadReader(strings.NewReader(yaml), config.LoadOptions{NoEnv: true})
+	if err != nil {
+		t.Fatalf("LoadReader: %v", err)
+	}
+	return New(cfg, context.Background())
+}
+
+type testBase struct {
+	Store testStore `inject:""`
+}
+
+type testInjected struct {
+	testBase
+	DB       *testDB       `inject:""`
+	Replica  *testDB       `inject:"replica"`
+	Port     uint          `config:"server.port"`
+	TokenTTL time.Duration `config:"auth.tokenTTL"`
+	Audience string        `config:"auth.audience,optional"`
+	Untagged *testDB
+}
+
+func TestInject_FieldsAndConfig(t *testing.T) {
+	app := injectTestApp(t, "server:\n  port: 9000\nauth:\n  tokenTTL: 15m\n")
+	ctx := app.Context
+	primary := &testDB{dsn: "primary"}
+	Provide(ctx, primary)
+	Provide(ctx, &testDB{dsn: "replica"}, Named("replica"))
+	Provide[testStore](ctx, &testMemStore{})
+
+	svc := testInjected{Audience: "default"}
+	if err := Inject(ctx, &svc); err != nil {
+		t.Fatalf("Inject: %v", err)
+	}
+	if svc.DB != primary || svc.Replica.dsn != "replica" || svc.Store == nil || svc.Untagged != nil {
+		t.Fatalf("unexpected dependencies %+v", svc)
+	}
+	if svc.Port != 9000 || svc.TokenTTL != 15*time.Minute || svc.Audience != "default" {
+		t.Fatalf("unexpected config values %+v", svc)
+	}
+}
+
+func TestInject_ReportsEveryField(t *testing.T) {
+	app := injectTestApp(t, "name: inject\n")
+
+	var svc struct {
+		DB       *testDB `inject:""`
+		TokenTTL int     `config:"auth.tokenTTL"`
+		hidden   *testDB `inject:""`
+	}
+	err := Inject(app.Context, &svc)
+	var missing *MissingDependencyError
+	if !errors.As(err, &missing) {
+		t.Fatalf("expected a MissingDependencyError, got %v", err)
+	}
+	for _, want := range []string{
+		".DB: core: no *core.testDB provided",
+		".TokenTTL: extra config not found: auth.tokenTTL",
+		".hidden: field is unexported",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in:\n%v", want, err)
+		}
+	}
+	_ = svc.hidden
+
+	if err := Inject(app.Context, svc); err == nil {
+		t.Error("expected an error for a non-pointer target")
+	}
+}
+
+type testInjectModule struct {
+	DB      *testDB `inject:""`
+	AppName string  `config:"name"`
+	inited  bool
+}
+
+func (m *testInjectModule) Name() string            { return "inject" }
+func (m *testInjectModule) Priority() uint          { return 0 }
+func (m *testInjectModule) Init(*Context) error     { m.inited = m.DB != nil; return nil }
+func (m *testInjectModule) Start(*Context) error    { return nil }
+func (m *testInjectModule) Shutdown(*Context) error { return nil }
+
+func TestInitAll_InjectsModules(t *testing.T) {
+	app := injectTestApp(t, "name: inject-app\n")
+	Provide(app.Context, &testDB{})
+	mod := &testInjectModule{}
+	app.UseModule(mod)
+	if err := app.moduleManager.InitAll(); err != nil {
+		t.Fatalf("InitAll: %v", err)
+	}
+	if !mod.inited || mod.AppName != "inject-app" {
+		t.Fatalf("expected the module to be injected before Init, got %+v", mod)
+	}
+}
+
+type testOrderHandler struct {
+	DB *testDB `inject:""`
+}
+
+func (h *testOrderHandler) Routes(r *Router) {
+	r.GET("/orders", h.list)
+}
+
+func (h *testOrderHandler) list(rc *RequestContext) error {
+	return rc.Text(http.StatusOK, h.DB.dsn)
+}
+
+func TestRouterHandle_InjectsHandlers(t *testing.T) {
+	ctx := newTestContext()
+	Provide(ctx, &testDB{dsn: "orders-db"})
+	router := NewRouter(ctx)
+	router.Handle(&testOrderHandler{})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders", nil))
+	if rec.Body.String() != "orders-db" {
+		t.Fatalf("expected the injected handler to serve, got %d %q", rec.Code, rec.Body)
+	}
+}
+
+func TestInject_ConfigFollowsReloads(t *testing.T) {
+	app := injectTestApp(t, "name: before\n")
+	snapshot := app.Config
+	app.Config = &config.LiliumConfig{Name: "after"} // what CurrentConfig returns without a watcher
+
+	var svc struct {
+		Name string     `config:"name"`
+		Live LiveConfig `inject:""`
+	}
+	if err := Inject(app.Context, &svc); err != nil {
+		t.Fatalf("Inject: %v", err)
+	}
+	if svc.Name != "after" || svc.Live().Name != "after" {
+		t.Fatalf("expected the current config, got %q and %q", svc.Name, svc.Live().Name)
+	}
+	if cfg := MustResolve[*config.LiliumConfig](app.Context); cfg != snapshot {
+		t.Fatal("expected *config.LiliumConfig to stay the start-up snapshot")
+	}
+}
@@ -2,7 +2,9 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"net/http"
 	"os"
 	"os/signal"
@@ -20,9 +22,11 @@ type Lilium struct {
 	onStopTasks   []LiliumTask
 	Lock          *sync.Mutex
 	Logger        *logger.Logger
+	AccessLog     *logger.AccessLogger // nil unless Logger.Access.Enabled
 	Context       *Context
 	isRunning     bool
 	moduleManager *ModuleManager
+	configWatcher *config.Watcher // nil unless Config.Reload.Enabled
 }
 
 func New(cfg *config.LiliumConfig, ctx_ context.Context) *Lilium {
@@ -30,6 +34,17 @@ func New(cfg *config.LiliumConfig, ctx_ context.Context) *Lilium {
 	if err != nil {
 		panic(fmt.Sprintf("Unable to instantiate logger: %v", err))
 	}
+	for _, w := range cfg.Warnings {
+		log.Warn(w)
+	}
+
+	var accessLog *logger.AccessLogger
+	if cfg.Logger != nil && cfg.Logger.Access != nil && cfg.Logger.Access.Enabled {
+		accessLog, err = logger.NewAccessLogger(cfg.Logger.Access, log.Redactor())
+		if err != nil {
+			panic(fmt.Sprintf("Unable to instantiate access log: %v", err))
+		}
+	}
 
 	app := &Lilium{
 		Config:       cfg,
@@ -37,6 +52,7 @@ func New(cfg *config.LiliumConfig, ctx_ context.Context) *Lilium {
 		onStopTasks:  []LiliumTask{},
 		Lock:         &sync.Mutex{},
 		Logger:       log,
+		AccessLog:    accessLog,
 		isRunning:    false,
 	}
 
@@ -51,7 +67,12 @@ func New(cfg *config.LiliumConfig, ctx_ context.Context) *Lilium {
 	}
 
 	app.Context = ctx
+	Provide(ctx, cfg) // start-up snapshot; LiveConfig follows reloads
+	Provide(ctx, LiveConfig(app.CurrentConfig))
+	Provide(ctx, log, NoDispose()) // closed last, by Start
+	Provide(ctx, ctx)
 	app.moduleManager = NewModuleManager(ctx)
+	app.setupConfigWatcher()
 
 	return app
 }
@@ -59,18 +80,44 @@ func New(cfg *config.LiliumConfig, ctx_ context.Context) *Lilium {
 const DEFAULT_LILIUM_CONFIG = "lilium.yaml"
 
 func LoadLiliumConfig(path string) *config.LiliumConfig {
-	envCfg, _ := config.LoadEnv(path)
-	if envCfg != nil {
-		processEnv(envCfg)
-	}
-	cfg, err := config.Load(path)
+	return loadLiliumConfig(path, config.LoadOptions{
+		Profile: config.ProfileFromArgs(os.Args[1:]),
+	})
+}
+
+// LoadLiliumConfigFlags loads the config named by --config with the profile
+// and overrides given on the command line; see config.BindFlags.
+func LoadLiliumConfigFlags(flags *config.Flags) *config.LiliumConfig {
+	return loadLiliumConfig(flags.ConfigPath(), flags.Options())
+}
+
+func loadLiliumConfig(path string, opts config.LoadOptions) *config.LiliumConfig {
+	cfg, err := ReadLiliumConfig(path, opts)
 	if err != nil {
 		panic(fmt.Sprintf("Error while reading the lilium config at %s: %v\n", path, err))
 	}
-
 	return cfg
 }
 
+// ReadLiliumConfig loads the .env files enabled by the env section of path,
+// then the config itself, and validates it. Unlike LoadLiliumConfig it
+// returns errors instead of panicking.
+func ReadLiliumConfig(path string, opts config.LoadOptions) (*config.LiliumConfig, error) {
+	envCfg, err := config.LoadEnv(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	profile := opts.Profile
+	if profile == "" {
+		profile = os.Getenv(config.ProfileEnv)
+	}
+	if err := processEnv(envCfg, profile, opts.NoLocal); err != nil {
+		return nil, err
+	}
+
+	return config.LoadWithOptions(path, opts)
+}
+
 func (app *Lilium) OnStart(task LiliumTask) {
 	app.Lock.Lock()
 	defer app.Lock.Unlock()
@@ -96,16 +143,22 @@ func (app *Lilium) Start(router *Router) {
 		panic(err)
 	}
 
-	app.processCors(router.mux)
+	app.processAdmin(router.mux)
 
 	srv := &http.Server{
 		Addr:    fmt.Sprintf(":%d", app.Config.Server.Port),
-		Handler: router,
+		Handler: app.corsHandler(router),
 	}
 
 	app.Logger.Info("Mounting static files")
-	for _, s := range app.Config.Server.Static {
-		router.Static(s.Route, s.Directory)
+	if app.configWatcher != nil {
+		// Resolved per request so mounts follow config reloads; anything
+		// else still reaches the router's own NotFound handler.
+		router.mux.NotFound(app.staticHandler(router.mux.NotFoundHandler()))
+	} else {
+		for _, s := range app.Config.Server.Static {
+			router.Static(s.Route, s.Directory)
+		}
 	}
 	app.Logger.Info("Mounted static files")
 
@@ -137,10 +190,16 @@ func (app *Lilium) Start(router *Router) {
 		}
 	}()
 
+	// SIGUSR1 toggles debug logging without a restart
+	stopDebugToggle := app.Logger.ToggleDebugOnSignal(debugToggleSignals...)
+	stopConfigWatch := app.watchConfig()
+
 	// Wait for shutdown signal (Ctrl+C etc.)
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
+	stopDebugToggle()
+	stopConfigWatch()
 
 	app.Logger.Info("Shutting down server...")
 
@@ -164,7 +223,15 @@ func (app *Lilium) Start(router *Router) {
 	app.moduleManager.ShutdownAll()
 	app.Logger.Info("Stopped all the attached modules...")
 
-	// Close logger
+	app.Logger.Info("Closing provided dependencies...")
+	if err := app.Context.container().dispose(app.Config.Server.DisposeTimeout); err != nil {
+		app.Logger.Errorf("Error while closing dependencies: %v", err)
+	}
+
+	// Close loggers
+	if app.AccessLog != nil {
+		_ = app.AccessLog.Close()
+	}
 	_ = app.Logger.Close()
 
 	app.Logger.Info("Lilium shutdown complete.")
@@ -53,11 +53,23 @@ func (m *ModuleManager) InitAll() error {
 
 	for _, module := range m.modules {
 		m.app.Logger.Infof("→ Init %s", module.Name())
+		// Fields tagged inject or config are filled before Init.
+		if injectable(module) {
+			if err := Inject(m.app, module); err != nil {
+				return fmt.Errorf("init failed for %s: %w", module.Name(), err)
+			}
+		}
 		if err := module.Init(m.app); err != nil {
 			return fmt.Errorf("init failed for %s: %w", module.Name(), err)
 		}
 	}
 
+	// Modules provide their dependencies in Init; check them all now so a
+	// missing provider or cycle stops start-up instead of a request.
+	if err := m.app.container().start(); err != nil {
+		return fmt.Errorf("dependency injection failed: %w", err)
+	}
+
 	return nil
 }
 
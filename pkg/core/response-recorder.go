@@ -0,0 +1,65 @@
+package core
+
+import (
+	"net/http"
+)
+
+// ResponseRecorder wraps a ResponseWriter to capture the status code and the
+// number of body bytes written. Middleware should obtain one through
+// CaptureResponse so that a single wrapper is shared along the chain.
+type ResponseRecorder struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+// CaptureResponse returns w itself when it already is a *ResponseRecorder,
+// or wraps it in a new one.
+func CaptureResponse(w http.ResponseWriter) *ResponseRecorder {
+	if rr, ok := w.(*ResponseRecorder); ok {
+		return rr
+	}
+	return &ResponseRecorder{ResponseWriter: w}
+}
+
+func (rr *ResponseRecorder) WriteHeader(code int) {
+	if rr.status == 0 {
+		rr.status = code
+	}
+	rr.ResponseWriter.WriteHeader(code)
+}
+
+func (rr *ResponseRecorder) Write(p []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+	n, err := rr.ResponseWriter.Write(p)
+	rr.size += n
+	return n, err
+}
+
+// Status returns the status code sent so far, http.StatusOK if the handler
+// wrote nothing.
+func (rr *ResponseRecorder) Status() int {
+	if rr.status == 0 {
+		return http.StatusOK
+	}
+	return rr.status
+}
+
+// Size returns the number of body bytes written.
+func (rr *ResponseRecorder) Size() int {
+	return rr.size
+}
+
+// Flush keeps streaming responses working through the wrapper.
+func (rr *ResponseRecorder) Flush() {
+	if f, ok := rr.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rr *ResponseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
@@ -15,6 +15,7 @@ type RequestContext struct {
 	Params     map[string]string // path params
 	store      map[string]any    // per-request KV store
 	formParsed bool
+	scope      *diScope // request-scoped dependencies, see RequestScoped
 }
 
 type HandlerFunc func(*RequestContext) error
@@ -27,9 +28,23 @@ func NewRequestContext(app *Context, w http.ResponseWriter, r *http.Request) *Re
 		Res:    w,
 		Params: make(map[string]string),
 		store:  make(map[string]any),
+		scope:  &diScope{},
 	}
 }
 
+// Close closes the request-scoped dependencies built for the request,
+// newest first, each within server.disposeTimeout or its CloseTimeout. The
+// router calls it once the handler returns.
+func (c *RequestContext) Close() error {
+	var timeout time.Duration
+	if c.App != nil && c.App.app != nil {
+		if cfg := c.App.app.CurrentConfig(); cfg != nil && cfg.Server != nil {
+			timeout = cfg.Server.DisposeTimeout
+		}
+	}
+	return c.scope.close(timeout)
+}
+
 func (c *RequestContext) JSON(status int, v any) error {
 	c.Res.Header().Set("Content-Type", "application/json")
 	c.Res.WriteHeader(status)
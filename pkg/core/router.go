@@ -14,15 +14,23 @@ type requestContextKey struct{}
 type Router struct {
 	mux *chi.Mux
 	app *Context
+
+	prefix     string      // mount path of a sub-router, for CORS lookups
+	cors       *corsPolicy // set by WithCors
+	corsRoutes *corsRoutes // shared by every router derived from the root
 }
 
 func NewRouter(app *Context) *Router {
 	r := &Router{
-		mux: chi.NewRouter(),
-		app: app,
+		mux:        chi.NewRouter(),
+		app:        app,
+		corsRoutes: newCorsRoutes(),
 	}
 
 	r.mux.Use(middleware.Recoverer)
+	if app != nil && app.app != nil && app.app.AccessLog != nil {
+		r.mux.Use(accessLogHandler(app.app.AccessLog))
+	}
 
 	return r
 }
@@ -30,12 +38,8 @@ func NewRouter(app *Context) *Router {
 func (r *Router) adapt(h HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		// Reuse RequestContext threaded through by middleware, if present.
-		var ctx *RequestContext
-		if rc, ok := req.Context().Value(requestContextKey{}).(*RequestContext); ok {
-			ctx = rc
-		} else {
-			ctx = NewRequestContext(r.app, w, req)
-		}
+		ctx, owned := r.requestContext(w, req)
+		defer r.finish(ctx, owned)
 
 		// extract chi params
 		for _, key := range chi.RouteContext(req.Context()).URLParams.Keys {
@@ -49,34 +53,61 @@ func (r *Router) adapt(h HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// requestContext returns the RequestContext threaded through req by
+// middleware, or a new one that the caller owns and must close.
+func (r *Router) requestContext(w http.ResponseWriter, req *http.Request) (*RequestContext, bool) {
+	if rc, ok := req.Context().Value(requestContextKey{}).(*RequestContext); ok {
+		return rc, false
+	}
+	return NewRequestContext(r.app, w, req), true
+}
+
+// finish closes rc when the caller owns it, logging close errors.
+func (r *Router) finish(rc *RequestContext, owned bool) {
+	if !owned {
+		return
+	}
+	if err := rc.Close(); err != nil && r.app != nil && r.app.Logger != nil {
+		r.app.Logger.Errorf("closing request-scoped dependencies: %v", err)
+	}
+}
+
+func (r *Router) handle(method, path string, h HandlerFunc) {
+	r.mux.Method(method, path, r.adapt(h))
+	if r.cors != nil {
+		r.corsRoutes.add(method, r.prefix+path, r.cors)
+	}
+}
+
 func (r *Router) GET(path string, h HandlerFunc) {
-	r.mux.Get(path, r.adapt(h))
+	r.handle(http.MethodGet, path, h)
 }
 
 func (r *Router) POST(path string, h HandlerFunc) {
-	r.mux.Post(path, r.adapt(h))
+	r.handle(http.MethodPost, path, h)
 }
 
 func (r *Router) PUT(path string, h HandlerFunc) {
-	r.mux.Put(path, r.adapt(h))
+	r.handle(http.MethodPut, path, h)
 }
 
 func (r *Router) DELETE(path string, h HandlerFunc) {
-	r.mux.Delete(path, r.adapt(h))
+	r.handle(http.MethodDelete, path, h)
 }
 
 func (r *Router) PATCH(path string, h HandlerFunc) {
-	r.mux.Patch(path, r.adapt(h))
+	r.handle(http.MethodPatch, path, h)
 }
 
 func (r *Router) OPTIONS(path string, h HandlerFunc) {
-	r.mux.Options(path, r.adapt(h))
+	r.handle(http.MethodOptions, path, h)
 }
 
 func (r *Router) Group(fn func(g *Router)) {
 	r.mux.Group(func(cr chi.Router) {
-		gr := &Router{mux: cr.(*chi.Mux), app: r.app}
-		fn(gr)
+		gr := *r
+		gr.mux = cr.(*chi.Mux)
+		fn(&gr)
 	})
 }
 
@@ -85,7 +116,10 @@ func (r *Router) Use(mws ...Middleware) {
 		mw := mw
 		r.mux.Use(func(next http.Handler) http.Handler {
 			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-				rc := NewRequestContext(r.app, w, req)
+				// Outer middleware may already have created the RequestContext;
+				// sharing it keeps one dependency scope per request.
+				rc, owned := r.requestContext(w, req)
+				defer r.finish(rc, owned)
 				h := mw(func(rc *RequestContext) error {
 					// Thread the same RequestContext to downstream handlers/middleware.
 					next.ServeHTTP(w, req.WithContext(
@@ -111,10 +145,10 @@ func (r *Router) SubRouter(prefix string) *Router {
 
 	r.mux.Mount(prefix, subMux)
 
-	return &Router{
-		mux: subMux,
-		app: r.app,
-	}
+	sub := *r
+	sub.mux = subMux
+	sub.prefix = r.prefix + strings.TrimSuffix(prefix, "/")
+	return &sub
 }
 
 func (r *Router) Static(route, dir string) {
@@ -0,0 +1,418 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spyder01/lilium-go/pkg/config"
+)
+
+// Access log formats.
+const (
+	AccessFormatCommon   = "common"
+	AccessFormatCombined = "combined"
+	AccessFormatJSON     = "json"
+	AccessFormatTemplate = "template"
+)
+
+const (
+	commonTemplate   = `%h %l %u %t "%r" %>s %b`
+	combinedTemplate = commonTemplate + ` "%{Referer}i" "%{User-Agent}i"`
+)
+
+// AccessEntry describes one completed request.
+type AccessEntry struct {
+	Time           time.Time // when the request started
+	RemoteAddr     string
+	User           string
+	Method         string
+	Path           string
+	RawQuery       string
+	Proto          string
+	Status         int
+	Size           int
+	Duration       time.Duration
+	RequestHeader  http.Header
+	ResponseHeader http.Header
+}
+
+// AccessLogger writes one line per request to a dedicated destination,
+// separate from the application log. Lines are written asynchronously.
+type AccessLogger struct {
+	format   func(buf *bytes.Buffer, e *AccessEntry)
+	async    *AsyncWriter
+	closer   io.Closer
+	redactor *Redactor
+
+	skipPaths    map[string]bool
+	skipPrefixes []string
+	skipExts     map[string]bool
+
+	successes *SuccessSampler
+
+	bufs      sync.Pool
+	closeOnce sync.Once
+}
+
+// NewAccessLogger opens the access log described by cfg. Query strings and
+// headers pass through r before they are written; r may be nil.
+func NewAccessLogger(cfg *config.AccessLogConfig, r *Redactor) (*AccessLogger, error) {
+	if cfg == nil {
+		cfg = &config.AccessLogConfig{}
+	}
+
+	a := &AccessLogger{
+		redactor:  r,
+		skipPaths: make(map[string]bool),
+		skipExts:  make(map[string]bool),
+		successes: NewSuccessSampler(cfg.Sample2xx),
+		bufs:      sync.Pool{New: func() any { return new(bytes.Buffer) }},
+	}
+
+	var err error
+	switch strings.ToLower(cfg.Format) {
+	case "", AccessFormatCombined:
+		a.format, err = a.compileTemplate(combinedTemplate)
+	case AccessFormatCommon:
+		a.format, err = a.compileTemplate(commonTemplate)
+	case AccessFormatTemplate:
+		if cfg.Template == "" {
+			return nil, fmt.Errorf("access log: format %q needs a template", cfg.Format)
+		}
+		a.format, err = a.compileTemplate(cfg.Template)
+	case AccessFormatJSON:
+		a.format = a.formatJSON
+	default:
+		return nil, fmt.Errorf("access log: unknown format %q", cfg.Format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("access log: %w", err)
+	}
+
+	for _, p := range cfg.SkipPaths {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			a.skipPrefixes = append(a.skipPrefixes, prefix)
+		} else {
+			a.skipPaths[p] = true
+		}
+	}
+	for _, ext := range cfg.SkipExtensions {
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		a.skipExts[strings.ToLower(ext)] = true
+	}
+
+	var out io.Writer
+	switch cfg.FilePath {
+	case "-":
+		out = os.Stdout
+	case "":
+		return nil, fmt.Errorf("access log: no file path")
+	default:
+		rf, err := NewRotatingFile(cfg.FilePath, cfg.MaxSizeMB, cfg.MaxBackups)
+		if err != nil {
+			return nil, fmt.Errorf("access log: %w", err)
+		}
+		out, a.closer = rf, rf
+	}
+	a.async = NewAsyncWriter(out, 10000)
+
+	return a, nil
+}
+
+// Skip reports whether requests for urlPath are excluded by the skip rules.
+func (a *AccessLogger) Skip(urlPath string) bool {
+	if a.skipPaths[urlPath] {
+		return true
+	}
+	for _, p := range a.skipPrefixes {
+		if strings.HasPrefix(urlPath, p) {
+			return true
+		}
+	}
+	if len(a.skipExts) > 0 {
+		if ext := path.Ext(urlPath); ext != "" && a.skipExts[strings.ToLower(ext)] {
+			return true
+		}
+	}
+	return false
+}
+
+// Log writes e unless it is skipped or sampled out.
+func (a *AccessLogger) Log(e *AccessEntry) {
+	if a.Skip(e.Path) {
+		return
+	}
+	if !a.successes.Keep(e.Status) {
+		return
+	}
+
+	buf := a.bufs.Get().(*bytes.Buffer)
+	buf.Reset()
+	a.format(buf, e)
+	buf.WriteByte('\n')
+	_, _ = a.async.Write(buf.Bytes())
+	a.bufs.Put(buf)
+}
+
+// Close flushes pending lines and closes the access log file.
+func (a *AccessLogger) Close() error {
+	var err error
+	a.closeOnce.Do(func() {
+		a.async.Close()
+		if a.closer != nil {
+			err = a.closer.Close()
+		}
+	})
+	return err
+}
+
+type accessDirective func(buf *bytes.Buffer, e *AccessEntry)
+
+// compileTemplate turns an Apache-style format string into a formatter.
+// Supported directives:
+//
+//	%h remote host    %l "-"             %u user           %t [time]
+//	%r request line   %s, %>s status     %b size or "-"    %B size
+//	%D duration (µs)  %T duration (s)    %m method         %U path
+//	%q ?query         %H protocol        %% literal %
+//	%{Name}i request header, %{Name}o response header; the leading % may be
+//	omitted, as in "{X-Request-ID}i".
+func (a *AccessLogger) compileTemplate(tmpl string) (func(*bytes.Buffer, *AccessEntry), error) {
+	var parts []accessDirective
+	var lit strings.Builder
+
+	flush := func() {
+		if lit.Len() > 0 {
+			s := lit.String()
+			parts = append(parts, func(buf *bytes.Buffer, _ *AccessEntry) { buf.WriteString(s) })
+			lit.Reset()
+		}
+	}
+
+	for i := 0; i < len(tmpl); i++ {
+		c := tmpl[i]
+		switch {
+		case c == '{':
+			if d, n, ok := a.headerDirective(tmpl[i:]); ok {
+				flush()
+				parts = append(parts, d)
+				i += n - 1
+				continue
+			}
+			lit.WriteByte(c)
+
+		case c == '%':
+			if i+1 >= len(tmpl) {
+				return nil, fmt.Errorf("template %q ends with a lone %%", tmpl)
+			}
+			rest := tmpl[i+1:]
+			if rest[0] == '%' {
+				lit.WriteByte('%')
+				i++
+				continue
+			}
+			if rest[0] == '{' {
+				d, n, ok := a.headerDirective(rest)
+				if !ok {
+					return nil, fmt.Errorf("template %q: malformed %%{...} directive", tmpl)
+				}
+				flush()
+				parts = append(parts, d)
+				i += n
+				continue
+			}
+			if strings.HasPrefix(rest, ">s") {
+				rest = rest[1:]
+				i++
+			}
+			d, ok := a.directive(rest[0])
+			if !ok {
+				return nil, fmt.Errorf("template %q: unknown directive %%%c", tmpl, rest[0])
+			}
+			flush()
+			parts = append(parts, d)
+			i++
+
+		default:
+			lit.WriteByte(c)
+		}
+	}
+	flush()
+
+	return func(buf *bytes.Buffer, e *AccessEntry) {
+		for _, p := range parts {
+			p(buf, e)
+		}
+	}, nil
+}
+
+// headerDirective parses "{Name}i" or "{Name}o" at the start of s and
+// returns its formatter and length.
+func (a *AccessLogger) headerDirective(s string) (accessDirective, int, bool) {
+	end := strings.IndexByte(s, '}')
+	if end < 2 || end+1 >= len(s) {
+		return nil, 0, false
+	}
+	name := http.CanonicalHeaderKey(s[1:end])
+	switch s[end+1] {
+	case 'i':
+		return func(buf *bytes.Buffer, e *AccessEntry) {
+			writeAccessValue(buf, a.header(e.RequestHeader, name))
+		}, end + 2, true
+	case 'o':
+		return func(buf *bytes.Buffer, e *AccessEntry) {
+			writeAccessValue(buf, a.header(e.ResponseHeader, name))
+		}, end + 2, true
+	}
+	return nil, 0, false
+}
+
+func (a *AccessLogger) directive(c byte) (accessDirective, bool) {
+	switch c {
+	case 'h':
+		return func(buf *bytes.Buffer, e *AccessEntry) { writeAccessValue(buf, remoteHost(e.RemoteAddr)) }, true
+	case 'l':
+		return func(buf *bytes.Buffer, _ *AccessEntry) { buf.WriteByte('-') }, true
+	case 'u':
+		return func(buf *bytes.Buffer, e *AccessEntry) { writeAccessValue(buf, e.User) }, true
+	case 't':
+		return func(buf *bytes.Buffer, e *AccessEntry) {
+			buf.WriteByte('[')
+			buf.WriteString(e.Time.Format("02/Jan/2006:15:04:05 -0700"))
+			buf.WriteByte(']')
+		}, true
+	case 'r':
+		return func(buf *bytes.Buffer, e *AccessEntry) {
+			writeAccessValue(buf, e.Method+" "+a.uri(e)+" "+e.Proto)
+		}, true
+	case 's':
+		return func(buf *bytes.Buffer, e *AccessEntry) { buf.WriteString(strconv.Itoa(e.Status)) }, true
+	case 'b':
+		return func(buf *bytes.Buffer, e *AccessEntry) {
+			if e.Size == 0 {
+				buf.WriteByte('-')
+			} else {
+				buf.WriteString(strconv.Itoa(e.Size))
+			}
+		}, true
+	case 'B':
+		return func(buf *bytes.Buffer, e *AccessEntry) { buf.WriteString(strconv.Itoa(e.Size)) }, true
+	case 'D':
+		return func(buf *bytes.Buffer, e *AccessEntry) {
+			buf.WriteString(strconv.FormatInt(e.Duration.Microseconds(), 10))
+		}, true
+	case 'T':
+		return func(buf *bytes.Buffer, e *AccessEntry) {
+			buf.WriteString(strconv.FormatInt(int64(e.Duration/time.Second), 10))
+		}, true
+	case 'm':
+		return func(buf *bytes.Buffer, e *AccessEntry) { writeAccessValue(buf, e.Method) }, true
+	case 'U':
+		return func(buf *bytes.Buffer, e *AccessEntry) { writeAccessValue(buf, e.Path) }, true
+	case 'q':
+		return func(buf *bytes.Buffer, e *AccessEntry) {
+			if e.RawQuery != "" {
+				buf.WriteByte('?')
+				writeAccessValue(buf, a.redactor.Query(e.RawQuery))
+			}
+		}, true
+	case 'H':
+		return func(buf *bytes.Buffer, e *AccessEntry) { writeAccessValue(buf, e.Proto) }, true
+	}
+	return nil, false
+}
+
+func (a *AccessLogger) uri(e *AccessEntry) string {
+	if e.RawQuery == "" {
+		return e.Path
+	}
+	return e.Path + "?" + a.redactor.Query(e.RawQuery)
+}
+
+func (a *AccessLogger) header(h http.Header, name string) string {
+	v := h.Get(name)
+	if v == "" {
+		return ""
+	}
+	if a.redactor.MatchKey(name) {
+		return a.redactor.mask
+	}
+	return a.redactor.String(v)
+}
+
+type accessJSON struct {
+	Time       string  `json:"time"`
+	RemoteAddr string  `json:"remote_addr"`
+	User       string  `json:"user,omitempty"`
+	Method     string  `json:"method"`
+	Path       string  `json:"path"`
+	Query      string  `json:"query,omitempty"`
+	Proto      string  `json:"proto"`
+	Status     int     `json:"status"`
+	Size       int     `json:"size"`
+	DurationMs float64 `json:"duration_ms"`
+	Referer    string  `json:"referer,omitempty"`
+	UserAgent  string  `json:"user_agent,omitempty"`
+	RequestID  string  `json:"request_id,omitempty"`
+}
+
+func (a *AccessLogger) formatJSON(buf *bytes.Buffer, e *AccessEntry) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(accessJSON{
+		Time:       e.Time.Format(time.RFC3339),
+		RemoteAddr: remoteHost(e.RemoteAddr),
+		User:       e.User,
+		Method:     e.Method,
+		Path:       e.Path,
+		Query:      a.redactor.Query(e.RawQuery),
+		Proto:      e.Proto,
+		Status:     e.Status,
+		Size:       e.Size,
+		DurationMs: float64(e.Duration.Microseconds()) / 1000,
+		Referer:    a.header(e.RequestHeader, "Referer"),
+		UserAgent:  a.header(e.RequestHeader, "User-Agent"),
+		RequestID:  a.header(e.RequestHeader, "X-Request-Id"),
+	})
+	buf.Truncate(buf.Len() - 1) // Encode appends a newline
+}
+
+func remoteHost(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// writeAccessValue writes v, or "-" when empty, escaping quotes, backslashes
+// and control characters so values cannot break the line structure.
+func writeAccessValue(buf *bytes.Buffer, v string) {
+	if v == "" {
+		buf.WriteByte('-')
+		return
+	}
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		switch {
+		case c == '"' || c == '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(buf, `\x%02x`, c)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+}
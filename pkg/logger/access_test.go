@@ -0,0 +1,170 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spyder01/lilium-go/pkg/config"
+)
+
+func newTestAccessLogger(t *testing.T, cfg config.AccessLogConfig, r *Redactor) (*AccessLogger, string) {
+	t.Helper()
+	cfg.FilePath = filepath.Join(t.TempDir(), "access.log")
+	a, err := NewAccessLogger(&cfg, r)
+	if err != nil {
+		t.Fatalf("NewAccessLogger returned error: %v", err)
+	}
+	return a, cfg.FilePath
+}
+
+func readAccessLines(t *testing.T, a *AccessLogger, path string) []string {
+	t.Helper()
+	if err := a.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read access log: %v", err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func testAccessEntry() *AccessEntry {
+	return &AccessEntry{
+		Time:       time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC),
+		RemoteAddr: "10.0.0.1:51234",
+		Method:     "GET",
+		Path:       "/orders",
+		RawQuery:   "id=7",
+		Proto:      "HTTP/1.1",
+		Status:     200,
+		Size:       512,
+		Duration:   1500 * time.Microsecond,
+		RequestHeader: http.Header{
+			"Referer":      {"https://example.com/"},
+			"User-Agent":   {"curl/8.0"},
+			"X-Request-Id": {"req-1"},
+		},
+		ResponseHeader: http.Header{"Content-Type": {"application/json"}},
+	}
+}
+
+func TestAccessLogger_Formats(t *testing.T) {
+	cases := []struct {
+		name string
+		cfg  config.AccessLogConfig
+		want string
+	}{
+		{"common", config.AccessLogConfig{Format: "common"},
+			`10.0.0.1 - - [04/Mar/2025:05:06:07 +0000] "GET /orders?id=7 HTTP/1.1" 200 512`},
+		{"combined", config.AccessLogConfig{Format: "combined"},
+			`10.0.0.1 - - [04/Mar/2025:05:06:07 +0000] "GET /orders?id=7 HTTP/1.1" 200 512 "https://example.com/" "curl/8.0"`},
+		{"template", config.AccessLogConfig{Format: "template", Template: "%h %r %s %b %D {X-Request-ID}i %{Content-Type}o 100%%"},
+			`10.0.0.1 GET /orders?id=7 HTTP/1.1 200 512 1500 req-1 application/json 100%`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a, path := newTestAccessLogger(t, tc.cfg, nil)
+			a.Log(testAccessEntry())
+			lines := readAccessLines(t, a, path)
+			if len(lines) != 1 || lines[0] != tc.want {
+				t.Fatalf("expected %q, got %v", tc.want, lines)
+			}
+		})
+	}
+}
+
+func TestAccessLogger_JSON(t *testing.T) {
+	a, path := newTestAccessLogger(t, config.AccessLogConfig{Format: "json"}, nil)
+	a.Log(testAccessEntry())
+
+	lines := readAccessLines(t, a, path)
+	var got map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &got); err != nil {
+		t.Fatalf("invalid JSON line %q: %v", lines[0], err)
+	}
+	if got["status"] != float64(200) || got["path"] != "/orders" || got["request_id"] != "req-1" || got["duration_ms"] != 1.5 {
+		t.Fatalf("unexpected JSON access line: %v", got)
+	}
+}
+
+func TestAccessLogger_RedactsQueryAndHeaders(t *testing.T) {
+	r, _ := NewRedactor(&config.RedactConfig{})
+	a, path := newTestAccessLogger(t, config.AccessLogConfig{Format: "template", Template: `"%r" {Authorization}i`}, r)
+
+	e := testAccessEntry()
+	e.RawQuery = "token=abc"
+	e.RequestHeader.Set("Authorization", "Bearer xyz")
+	a.Log(e)
+
+	lines := readAccessLines(t, a, path)
+	if strings.Contains(lines[0], "abc") || strings.Contains(lines[0], "xyz") {
+		t.Fatalf("secrets leaked into access log: %q", lines[0])
+	}
+}
+
+func TestAccessLogger_SkipRules(t *testing.T) {
+	a, path := newTestAccessLogger(t, config.AccessLogConfig{
+		Format:         "common",
+		SkipPaths:      []string{"/healthz", "/assets/*"},
+		SkipExtensions: []string{".css", "PNG"},
+	}, nil)
+
+	for _, p := range []string{"/healthz", "/assets/app.js", "/site.css", "/logo.png", "/orders"} {
+		e := testAccessEntry()
+		e.Path = p
+		a.Log(e)
+	}
+
+	lines := readAccessLines(t, a, path)
+	if len(lines) != 1 || !strings.Contains(lines[0], "/orders") {
+		t.Fatalf("expected only /orders to be logged, got %v", lines)
+	}
+}
+
+func TestAccessLogger_UnknownDirective(t *testing.T) {
+	_, err := NewAccessLogger(&config.AccessLogConfig{Format: "template", Template: "%Z", FilePath: "-"}, nil)
+	if err == nil {
+		t.Fatalf("expected an error for an unknown directive")
+	}
+}
+
+func TestRotatingFile_Rotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	rf, err := NewRotatingFile(path, 1, 2)
+	if err != nil {
+		t.Fatalf("NewRotatingFile returned error: %v", err)
+	}
+
+	chunk := []byte(strings.Repeat("x", 600<<10) + "\n")
+	for i := 0; i < 5; i++ {
+		if _, err := rf.Write(chunk); err != nil {
+			t.Fatalf("Write returned error: %v", err)
+		}
+	}
+	if err := rf.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("expected %s to exist: %v", name, err)
+		}
+		if info.Size() > 1<<20 {
+			t.Fatalf("%s exceeds the size limit: %d bytes", name, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("expected at most 2 backups, found %s.3", path)
+	}
+}
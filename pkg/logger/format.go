@@ -0,0 +1,191 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	stdlog "log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+	FormatLogfmt  = "logfmt"
+)
+
+// formatOptions are the presentation settings shared by every sink of a
+// Logger.
+type formatOptions struct {
+	prefix  string
+	flags   int
+	noColor bool
+}
+
+// newFormatWriter wraps out so that the JSON lines produced by zerolog are
+// rendered in the requested format. JSON output is passed through untouched;
+// the prefix only applies to the human-oriented formats.
+func newFormatWriter(out io.Writer, format string, opts formatOptions) (io.Writer, error) {
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		return out, nil
+
+	case "", FormatConsole:
+		cw := zerolog.ConsoleWriter{Out: out, NoColor: opts.noColor}
+		if opts.flags != 0 {
+			cw.TimeFormat = consoleTimeFormat(opts.flags)
+		}
+		if opts.flags&stdlog.LUTC != 0 {
+			cw.TimeLocation = time.UTC
+		}
+		if opts.prefix == "" {
+			return cw, nil
+		}
+		if opts.flags&stdlog.Lmsgprefix != 0 {
+			prefix := opts.prefix
+			cw.FormatMessage = func(i any) string {
+				if i == nil {
+					return prefix
+				}
+				return prefix + fmt.Sprint(i)
+			}
+			return cw, nil
+		}
+		cw.Out = &prefixWriter{prefix: []byte(opts.prefix), out: out}
+		return cw, nil
+
+	case FormatLogfmt:
+		return &logfmtWriter{out: out, prefix: opts.prefix, msgPrefix: opts.flags&stdlog.Lmsgprefix != 0}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+}
+
+// consoleTimeFormat maps the standard library's log flags to a time layout.
+func consoleTimeFormat(flags int) string {
+	var parts []string
+	if flags&stdlog.Ldate != 0 {
+		parts = append(parts, "2006/01/02")
+	}
+	if flags&(stdlog.Ltime|stdlog.Lmicroseconds) != 0 {
+		layout := "15:04:05"
+		if flags&stdlog.Lmicroseconds != 0 {
+			layout += ".000000"
+		}
+		parts = append(parts, layout)
+	}
+	return strings.Join(parts, " ")
+}
+
+// prefixWriter prepends a fixed prefix to every line written through it.
+type prefixWriter struct {
+	prefix []byte
+	out    io.Writer
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	buf := make([]byte, 0, len(w.prefix)+len(p))
+	buf = append(buf, w.prefix...)
+	buf = append(buf, p...)
+	if _, err := w.out.Write(buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// logfmtWriter renders zerolog JSON lines as logfmt key=value pairs.
+type logfmtWriter struct {
+	out       io.Writer
+	prefix    string
+	msgPrefix bool
+}
+
+var logfmtLeadingKeys = []string{
+	zerolog.TimestampFieldName,
+	zerolog.LevelFieldName,
+	"component",
+	zerolog.CallerFieldName,
+	zerolog.MessageFieldName,
+}
+
+func (w *logfmtWriter) Write(p []byte) (int, error) {
+	var evt map[string]any
+	d := json.NewDecoder(bytes.NewReader(p))
+	d.UseNumber()
+	if err := d.Decode(&evt); err != nil {
+		return 0, fmt.Errorf("cannot decode event: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if w.prefix != "" && !w.msgPrefix {
+		buf.WriteString(w.prefix)
+	}
+
+	writePair := func(key string, val any) {
+		if buf.Len() > 0 && buf.Bytes()[buf.Len()-1] != ' ' {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		buf.WriteString(logfmtValue(val))
+	}
+
+	for _, key := range logfmtLeadingKeys {
+		val, ok := evt[key]
+		if !ok {
+			continue
+		}
+		if key == zerolog.MessageFieldName && w.msgPrefix && w.prefix != "" {
+			val = w.prefix + fmt.Sprint(val)
+		}
+		writePair(key, val)
+		delete(evt, key)
+	}
+
+	keys := make([]string, 0, len(evt))
+	for k := range evt {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		writePair(k, evt[k])
+	}
+	buf.WriteByte('\n')
+
+	if _, err := w.out.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func logfmtValue(v any) string {
+	var s string
+	switch vv := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		s = vv
+	case json.Number:
+		return vv.String()
+	case bool:
+		return fmt.Sprint(vv)
+	default:
+		b, err := json.Marshal(vv)
+		if err != nil {
+			s = fmt.Sprint(vv)
+		} else {
+			s = string(b)
+		}
+	}
+
+	if s == "" || strings.ContainsAny(s, " =\"\t\n") {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
@@ -0,0 +1,185 @@
+package logger
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spyder01/lilium-go/pkg/config"
+)
+
+// SetLevel changes the minimum level of this logger: the base level for a
+// root logger, or the component override for a component logger.
+func (l *Logger) SetLevel(level zerolog.Level) {
+	l.changeLevel(l.component, level, false, 0, "api")
+}
+
+// SetLevelFor behaves like SetLevel but reverts the change once ttl expires.
+func (l *Logger) SetLevelFor(level zerolog.Level, ttl time.Duration) {
+	l.changeLevel(l.component, level, false, ttl, "api")
+}
+
+// SetComponentLevel overrides the level of a component below this logger.
+func (l *Logger) SetComponentLevel(name string, level zerolog.Level) {
+	l.changeLevel(l.childName(name), level, false, 0, "api")
+}
+
+// SetComponentLevelFor behaves like SetComponentLevel but reverts the change
+// once ttl expires.
+func (l *Logger) SetComponentLevelFor(name string, level zerolog.Level, ttl time.Duration) {
+	l.changeLevel(l.childName(name), level, false, ttl, "api")
+}
+
+// ClearComponentLevel removes a component override so the component follows
+// its parent again.
+func (l *Logger) ClearComponentLevel(name string) {
+	l.changeLevel(l.childName(name), zerolog.NoLevel, true, 0, "api")
+}
+
+// Levels returns the base level and a copy of every component override.
+func (l *Logger) Levels() (zerolog.Level, map[string]zerolog.Level) {
+	return l.levels.snapshot()
+}
+
+// ToggleDebug switches the base level to debug, or to info when debug is
+// already on. Toggling again restores the level from before the first
+// toggle, unless the level was changed in between. It returns the new base
+// level.
+func (l *Logger) ToggleDebug() zerolog.Level {
+	return l.toggleDebug("api")
+}
+
+// ToggleDebugOnSignal calls ToggleDebug every time one of sigs is received.
+// The returned function stops listening.
+func (l *Logger) ToggleDebugOnSignal(sigs ...os.Signal) func() {
+	if len(sigs) == 0 {
+		return func() {}
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ch:
+				l.toggleDebug("signal")
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// ApplyConfig brings the levels in line with a reloaded cfg: the base level
+// follows Level/DebugEnabled, listed components are set, and components
+// dropped from cfg.Levels are cleared. Overrides for components that cfg has
+// never listed, such as ones made through the admin endpoint, are kept.
+func (l *Logger) ApplyConfig(cfg *config.LogConfig) error {
+	base, components, err := parseConfigLevels(cfg)
+	if err != nil {
+		return err
+	}
+
+	l.levels.mu.Lock()
+	curBase := l.levels.base
+	current := make(map[string]zerolog.Level, len(l.levels.components))
+	for k, v := range l.levels.components {
+		current[k] = v
+	}
+	var dropped []string
+	for name := range l.levels.fromConfig {
+		if _, ok := components[name]; !ok {
+			dropped = append(dropped, name)
+		}
+	}
+	l.levels.fromConfig = make(map[string]bool, len(components))
+	for name := range components {
+		l.levels.fromConfig[name] = true
+	}
+	l.levels.mu.Unlock()
+
+	if base != curBase {
+		l.changeLevel("", base, false, 0, "config")
+	}
+	for name, lvl := range components {
+		if cur, ok := current[name]; !ok || cur != lvl {
+			l.changeLevel(name, lvl, false, 0, "config")
+		}
+	}
+	for _, name := range dropped {
+		if _, ok := current[name]; ok {
+			l.changeLevel(name, zerolog.NoLevel, true, 0, "config")
+		}
+	}
+	return nil
+}
+
+func (l *Logger) toggleDebug(source string) zerolog.Level {
+	lv := l.levels
+	lv.mu.Lock()
+	var next zerolog.Level
+	switch {
+	case lv.toggle != nil && lv.base == lv.toggle.to:
+		next = lv.toggle.from
+		lv.toggle = nil
+	case lv.base == zerolog.DebugLevel:
+		next = zerolog.InfoLevel
+		lv.toggle = &levelToggle{from: lv.base, to: next}
+	default:
+		next = zerolog.DebugLevel
+		lv.toggle = &levelToggle{from: lv.base, to: next}
+	}
+	lv.mu.Unlock()
+
+	l.changeLevel("", next, false, 0, source)
+	return next
+}
+
+func (l *Logger) childName(name string) string {
+	switch {
+	case name == "":
+		return l.component
+	case l.component == "":
+		return name
+	}
+	return l.component + "." + name
+}
+
+// changeLevel applies a level change and records it in the log. Changes are
+// written regardless of the current level so they are never lost.
+func (l *Logger) changeLevel(component string, level zerolog.Level, clear bool, ttl time.Duration, source string) {
+	revert := func(prev zerolog.Level, had bool) {
+		l.changeLevel(component, prev, !had, 0, "ttl")
+	}
+	prev, had := l.levels.set(component, level, clear, ttl, revert)
+
+	// Audit records are never sampled.
+	e := l.unsampledEvent(zerolog.WarnLevel).Str("source", source)
+	if component != "" {
+		e = e.Str("target", component)
+	}
+	if had {
+		e = e.Str("from", prev.String())
+	}
+	if clear {
+		e = e.Str("to", "inherit")
+	} else {
+		e = e.Str("to", level.String())
+	}
+	if ttl > 0 {
+		e = e.Dur("ttl", ttl)
+	}
+	e.Msg("log level changed")
+}
@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type levelState struct {
+	Level      string            `json:"level"`
+	Components map[string]string `json:"components"`
+}
+
+type levelChange struct {
+	Level     string `json:"level"`
+	Component string `json:"component,omitempty"`
+	TTL       string `json:"ttl,omitempty"` // e.g. "10m"; the change reverts afterwards
+}
+
+// LevelHandler exposes the levels of l over HTTP:
+//
+//	GET     returns the base level and all component overrides
+//	PUT     {"level":"debug","component":"http","ttl":"10m"} changes a level
+//	DELETE  ?component=http removes a component override
+//
+// It performs no authentication of its own; mount it behind one.
+func LevelHandler(l *Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+
+		case http.MethodPut:
+			var req levelChange
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeLevelError(w, http.StatusBadRequest, fmt.Sprintf("invalid body: %v", err))
+				return
+			}
+			level, err := ParseLevel(req.Level, 0)
+			if err != nil || req.Level == "" {
+				writeLevelError(w, http.StatusBadRequest, fmt.Sprintf("invalid level %q", req.Level))
+				return
+			}
+			var ttl time.Duration
+			if req.TTL != "" {
+				if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl < 0 {
+					writeLevelError(w, http.StatusBadRequest, fmt.Sprintf("invalid ttl %q", req.TTL))
+					return
+				}
+			}
+			l.changeLevel(l.childName(req.Component), level, false, ttl, "http")
+
+		case http.MethodDelete:
+			component := r.URL.Query().Get("component")
+			if component == "" {
+				writeLevelError(w, http.StatusBadRequest, "component is required")
+				return
+			}
+			l.changeLevel(l.childName(component), 0, true, 0, "http")
+
+		default:
+			w.Header().Set("Allow", "GET, PUT, DELETE")
+			writeLevelError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		base, components := l.Levels()
+		state := levelState{Level: base.String(), Components: make(map[string]string, len(components))}
+		for name, lvl := range components {
+			state.Components[name] = lvl.String()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(state)
+	})
+}
+
+func writeLevelError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
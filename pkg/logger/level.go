@@ -0,0 +1,192 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spyder01/lilium-go/pkg/config"
+)
+
+// ParseLevel converts a config level name (trace, debug, info, warn, error,
+// fatal) into a zerolog level. An empty name yields fallback.
+func ParseLevel(name string, fallback zerolog.Level) (zerolog.Level, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch name {
+	case "":
+		return fallback, nil
+	case "warning":
+		return zerolog.WarnLevel, nil
+	}
+
+	lvl, err := zerolog.ParseLevel(name)
+	if err != nil || lvl == zerolog.NoLevel {
+		return fallback, fmt.Errorf("unknown log level %q", name)
+	}
+	return lvl, nil
+}
+
+// parseConfigLevels returns the base level and component overrides described
+// by cfg.
+func parseConfigLevels(cfg *config.LogConfig) (zerolog.Level, map[string]zerolog.Level, error) {
+	fallback := zerolog.InfoLevel
+	if cfg.DebugEnabled {
+		fallback = zerolog.DebugLevel
+	}
+	base, err := ParseLevel(cfg.Level, fallback)
+	if err != nil {
+		return base, nil, fmt.Errorf("logger.level: %w", err)
+	}
+
+	components := make(map[string]zerolog.Level, len(cfg.Levels))
+	for name, value := range cfg.Levels {
+		lvl, err := ParseLevel(value, base)
+		if err != nil {
+			return base, nil, fmt.Errorf("logger.levels.%s: %w", name, err)
+		}
+		components[name] = lvl
+	}
+	return base, components, nil
+}
+
+// ValidateLevels reports whether every level named in cfg is known.
+func ValidateLevels(cfg *config.LogConfig) error {
+	if cfg == nil {
+		return nil
+	}
+	_, _, err := parseConfigLevels(cfg)
+	return err
+}
+
+// levels holds the base level of a Logger tree together with any
+// per-component overrides. It is shared by a Logger and all of its
+// component children.
+type levels struct {
+	mu         sync.RWMutex
+	base       zerolog.Level
+	toggle     *levelToggle // last ToggleDebug change, nil when none is active
+	components map[string]zerolog.Level
+	fromConfig map[string]bool           // components whose override came from LogConfig.Levels
+	reverts    map[string]*pendingRevert // keyed by component, "" for the base level
+}
+
+// levelToggle records a ToggleDebug change so the next toggle can undo it.
+type levelToggle struct {
+	from, to zerolog.Level
+}
+
+// pendingRevert restores a level once a temporary change expires.
+type pendingRevert struct {
+	timer *time.Timer
+	level zerolog.Level
+	had   bool
+}
+
+func newLevels(base zerolog.Level) *levels {
+	return &levels{
+		base:       base,
+		components: make(map[string]zerolog.Level),
+		fromConfig: make(map[string]bool),
+		reverts:    make(map[string]*pendingRevert),
+	}
+}
+
+func (lv *levels) get(component string) zerolog.Level {
+	lv.mu.RLock()
+	defer lv.mu.RUnlock()
+
+	// "http.access" falls back to "http" before the base level.
+	for component != "" {
+		if lvl, ok := lv.components[component]; ok {
+			return lvl
+		}
+		i := strings.LastIndexByte(component, '.')
+		if i < 0 {
+			break
+		}
+		component = component[:i]
+	}
+	return lv.base
+}
+
+// lookup returns the level stored for component ("" for the base level)
+// without walking up parent components.
+func (lv *levels) lookup(component string) (zerolog.Level, bool) {
+	if component == "" {
+		return lv.base, true
+	}
+	lvl, ok := lv.components[component]
+	return lvl, ok
+}
+
+func (lv *levels) store(component string, lvl zerolog.Level, ok bool) {
+	switch {
+	case component == "":
+		lv.base = lvl
+	case ok:
+		lv.components[component] = lvl
+	default:
+		delete(lv.components, component)
+	}
+}
+
+// set stores a level (or clears a component override when clear is true)
+// and returns the previous state. A positive ttl schedules revert to run
+// once it expires; a pending revert keeps its original target so stacked
+// temporary changes still return to the level that was active before them.
+func (lv *levels) set(component string, lvl zerolog.Level, clear bool, ttl time.Duration, revert func(lvl zerolog.Level, had bool)) (zerolog.Level, bool) {
+	lv.mu.Lock()
+	defer lv.mu.Unlock()
+
+	prev, had := lv.lookup(component)
+	lv.store(component, lvl, !clear)
+
+	pending := lv.reverts[component]
+	if pending != nil {
+		pending.timer.Stop()
+		delete(lv.reverts, component)
+	}
+
+	if ttl > 0 {
+		target := &pendingRevert{level: prev, had: had}
+		if pending != nil {
+			target.level, target.had = pending.level, pending.had
+		}
+		target.timer = time.AfterFunc(ttl, func() {
+			lv.mu.Lock()
+			if lv.reverts[component] != target {
+				lv.mu.Unlock()
+				return
+			}
+			delete(lv.reverts, component)
+			lv.mu.Unlock()
+			revert(target.level, target.had)
+		})
+		lv.reverts[component] = target
+	}
+
+	return prev, had
+}
+
+func (lv *levels) snapshot() (zerolog.Level, map[string]zerolog.Level) {
+	lv.mu.RLock()
+	defer lv.mu.RUnlock()
+
+	out := make(map[string]zerolog.Level, len(lv.components))
+	for k, v := range lv.components {
+		out[k] = v
+	}
+	return lv.base, out
+}
+
+func (lv *levels) stopReverts() {
+	lv.mu.Lock()
+	defer lv.mu.Unlock()
+
+	for k, p := range lv.reverts {
+		p.timer.Stop()
+		delete(lv.reverts, k)
+	}
+}
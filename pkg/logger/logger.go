@@ -1,28 +1,44 @@
 package logger
 
 import (
+	"fmt"
 	"io"
+	stdlog "log"
 	"os"
-	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
 	"github.com/spyder01/lilium-go/pkg/config"
 )
 
+type asyncEntry struct {
+	level zerolog.Level
+	p     []byte
+}
+
 type AsyncWriter struct {
-	ch   chan []byte
-	done chan struct{}
+	ch     chan asyncEntry
+	done   chan struct{}
+	mu     sync.RWMutex // guards closed so Write never sends on a closed channel
+	closed bool
 }
 
 func NewAsyncWriter(w io.Writer, bufferSize int) *AsyncWriter {
+	lw, ok := w.(zerolog.LevelWriter)
+	if !ok {
+		lw = zerolog.LevelWriterAdapter{Writer: w}
+	}
+
 	aw := &AsyncWriter{
-		ch:   make(chan []byte, bufferSize),
+		ch:   make(chan asyncEntry, bufferSize),
 		done: make(chan struct{}),
 	}
 	go func() {
-		for msg := range aw.ch {
-			_, _ = w.Write(msg)
+		for e := range aw.ch {
+			_, _ = lw.WriteLevel(e.level, e.p)
 		}
 		close(aw.done)
 	}()
@@ -30,18 +46,25 @@ func NewAsyncWriter(w io.Writer, bufferSize int) *AsyncWriter {
 }
 
 func (a *AsyncWriter) Write(p []byte) (int, error) {
+	return a.WriteLevel(zerolog.NoLevel, p)
+}
+
+// WriteLevel implements zerolog.LevelWriter so the level of each event
+// survives the hop to the background goroutine.
+func (a *AsyncWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
 	// If already closed, drop safely
-	select {
-	case <-a.done:
+	if a.closed {
 		return len(p), nil
-	default:
 	}
 
 	cp := make([]byte, len(p))
 	copy(cp, p)
 
 	select {
-	case a.ch <- cp:
+	case a.ch <- asyncEntry{level: level, p: cp}:
 	default:
 		// buffer full–drop log
 	}
@@ -49,14 +72,46 @@ func (a *AsyncWriter) Write(p []byte) (int, error) {
 }
 
 func (a *AsyncWriter) Close() {
-	close(a.ch)
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.ch)
+	}
+	a.mu.Unlock()
 	<-a.done
 }
 
-type Logger struct {
-	file        *os.File
-	log         zerolog.Logger
+// levelWriter tags everything written through it with a fixed level.
+type levelWriter struct {
+	w     zerolog.LevelWriter
+	level zerolog.Level
+}
+
+func (w levelWriter) Write(p []byte) (int, error) {
+	return w.w.WriteLevel(w.level, p)
+}
+
+// loggerCore is the state shared by a Logger and all of its components.
+type loggerCore struct {
+	sinks       *fanout
 	asyncWriter *AsyncWriter
+	sampler     *sampler
+	flags       int
+	closeOnce   sync.Once
+}
+
+// Logger is a leveled logger backed by zerolog. Levels are tracked per
+// Logger rather than through zerolog's process-wide settings, so several
+// Lilium apps (or tests) can share a process without interfering.
+type Logger struct {
+	component string
+	levels    *levels
+	core      *loggerCore
+
+	unsampled bool
+
+	ctx     zerolog.Logger
+	byLevel [levelCount]zerolog.Logger
 }
 
 func NewLogger(cfg *config.LogConfig) (*Logger, error) {
@@ -64,69 +119,273 @@ func NewLogger(cfg *config.LogConfig) (*Logger, error) {
 		cfg = &config.LogConfig{}
 	}
 
-	var writers []io.Writer
+	base, components, err := parseConfigLevels(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-	if cfg.ToStdout {
-		writers = append(writers, zerolog.ConsoleWriter{Out: os.Stdout})
+	lv := newLevels(base)
+	for name, lvl := range components {
+		lv.components[name] = lvl
+		lv.fromConfig[name] = true
 	}
 
-	var file *os.File
+	redactor, err := NewRedactor(cfg.Redact)
+	if err != nil {
+		return nil, fmt.Errorf("logger.redact: %w", err)
+	}
+
+	smp, err := newSampler(cfg.Sampling)
+	if err != nil {
+		return nil, fmt.Errorf("logger.sampling: %w", err)
+	}
+
+	opts := formatOptions{prefix: cfg.Prefix, flags: cfg.Flags}
+
+	sinkCfgs := make([]config.SinkConfig, 0, len(cfg.Sinks)+2)
+	if cfg.ToStdout {
+		sinkCfgs = append(sinkCfgs, config.SinkConfig{Type: "stdout", Format: cfg.StdoutFormat})
+	}
 	if cfg.ToFile {
 		if cfg.FilePath == "" {
 			cfg.FilePath = "lilium.log"
 		}
-		dir := filepath.Dir(cfg.FilePath)
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return nil, err
-		}
-		f, err := os.OpenFile(cfg.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		sinkCfgs = append(sinkCfgs, config.SinkConfig{Type: "file", Path: cfg.FilePath, Format: cfg.FileFormat})
+	}
+	sinkCfgs = append(sinkCfgs, cfg.Sinks...)
+
+	sinks := &fanout{redactor: redactor}
+	for _, sc := range sinkCfgs {
+		s, err := newSink(sc, opts)
 		if err != nil {
-			return nil, err
+			_ = sinks.close()
+			return nil, fmt.Errorf("logger: %s sink: %w", sc.Type, err)
 		}
-		file = f
-		writers = append(writers, f)
+		sinks.add(s)
 	}
 
-	multi := io.MultiWriter(writers...)
-	async := NewAsyncWriter(multi, 10000)
+	core := &loggerCore{
+		sinks:       sinks,
+		asyncWriter: NewAsyncWriter(sinks, 10000),
+		sampler:     smp,
+		flags:       cfg.Flags,
+	}
 
-	zlog := zerolog.New(async).With().Timestamp().Logger()
+	l := &Logger{levels: lv, core: core}
+	l.bind(zerolog.New(io.Discard))
+	if smp != nil {
+		smp.start(l.emitSuppressed)
+	}
+	return l, nil
+}
 
-	if cfg.DebugEnabled {
-		zerolog.SetGlobalLevel(zerolog.DebugLevel)
-	} else {
-		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+// bind installs ctx as the logger's context and derives one zerolog logger
+// per level, each tagged so sinks see the real level of every event.
+func (l *Logger) bind(ctx zerolog.Logger) {
+	l.ctx = ctx
+	for lvl := zerolog.TraceLevel; lvl <= zerolog.FatalLevel; lvl++ {
+		zl := ctx
+		if l.core.sampler != nil && !l.unsampled {
+			zl = zl.Hook(samplingHook{s: l.core.sampler, level: lvl, component: l.component})
+		}
+		l.byLevel[lvl-zerolog.TraceLevel] = zl.Output(levelWriter{w: l.core.asyncWriter, level: lvl})
 	}
+}
 
-	log.Logger = zlog
+// emitSuppressed writes a sampler summary.
+func (l *Logger) emitSuppressed(key sampleKey, suppressed uint64) {
+	e := l.unsampledEvent(key.level)
+	if key.component != "" {
+		e = e.Str("component", key.component)
+	}
+	e.Str("sampled_message", key.msg).
+		Uint64("suppressed", suppressed).
+		Msgf("suppressed %d similar messages", suppressed)
+}
 
-	return &Logger{
-		file:        file,
-		log:         zlog,
-		asyncWriter: async,
-	}, nil
+// Component returns a child logger that tags its events with the given
+// component name and honors any level override configured for it.
+// Nested components are joined with a dot, e.g. "http.access".
+func (l *Logger) Component(name string) *Logger {
+	name = l.childName(name)
+	child := &Logger{component: name, levels: l.levels, core: l.core, unsampled: l.unsampled}
+	child.bind(l.ctx.With().Str("component", name).Logger())
+	return child
 }
 
-func (l *Logger) Info(msg string)  { l.log.Info().Msg(msg) }
-func (l *Logger) Warn(msg string)  { l.log.Warn().Msg(msg) }
-func (l *Logger) Debug(msg string) { l.log.Debug().Msg(msg) }
-func (l *Logger) Error(msg string) { l.log.Error().Msg(msg) }
+// WithoutSampling returns a copy of the logger that is exempt from
+// LogConfig.Sampling, for streams that apply their own sampling rules.
+func (l *Logger) WithoutSampling() *Logger {
+	child := &Logger{component: l.component, levels: l.levels, core: l.core, unsampled: true}
+	child.bind(l.ctx)
+	return child
+}
 
-func (l *Logger) Infof(format string, args ...interface{})  { l.log.Info().Msgf(format, args...) }
-func (l *Logger) Warnf(format string, args ...interface{})  { l.log.Warn().Msgf(format, args...) }
-func (l *Logger) Debugf(format string, args ...interface{}) { l.log.Debug().Msgf(format, args...) }
-func (l *Logger) Errorf(format string, args ...interface{}) { l.log.Error().Msgf(format, args...) }
+// Redactor returns the redactor applied to every event, or nil when
+// redaction is not configured. Its methods are safe to call on nil.
+func (l *Logger) Redactor() *Redactor {
+	l.core.sinks.mu.RLock()
+	defer l.core.sinks.mu.RUnlock()
+	return l.core.sinks.redactor
+}
 
-func (l *Logger) Close() error {
-	if l.asyncWriter != nil {
-		l.asyncWriter.Close()
+// RedactValues masks values as exact text in every event from now on. It
+// turns redaction on, with the default keys, when it was not configured.
+func (l *Logger) RedactValues(values ...string) {
+	if len(values) == 0 {
+		return
 	}
-	if l.file != nil {
-		return l.file.Close()
+	f := l.core.sinks
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.redactor == nil {
+		f.redactor, _ = NewRedactor(&config.RedactConfig{})
 	}
-	return nil
+	f.redactor.AddValues(values...)
+}
+
+// Level returns the minimum level currently enabled for this logger.
+func (l *Logger) Level() zerolog.Level {
+	return l.levels.get(l.component)
+}
+
+// Enabled reports whether events at the given level would be written.
+func (l *Logger) Enabled(level zerolog.Level) bool {
+	return level >= l.Level()
+}
+
+// newEvent must be called directly from the exported logging methods so the
+// caller depth used for the Lshortfile/Llongfile flags stays correct.
+func (l *Logger) newEvent(level zerolog.Level) *zerolog.Event {
+	if !l.Enabled(level) {
+		return nil
+	}
+
+	var pc uintptr
+	if l.core.flags&(stdlog.Lshortfile|stdlog.Llongfile) != 0 {
+		pc, _, _, _ = runtime.Caller(2)
+	}
+	return l.buildEvent(level, time.Now(), pc)
+}
+
+// buildEvent creates an event without consulting the logger's level. pc
+// identifies the call site reported by the Lshortfile/Llongfile flags; zero
+// omits it.
+func (l *Logger) buildEvent(level zerolog.Level, t time.Time, pc uintptr) *zerolog.Event {
+	return l.decorate(l.byLevel[level-zerolog.TraceLevel], level, t, pc)
+}
+
+// unsampledEvent creates an event like buildEvent that bypasses the
+// sampling hooks, which are only installed on the per-level loggers.
+func (l *Logger) unsampledEvent(level zerolog.Level) *zerolog.Event {
+	zl := l.ctx.Output(levelWriter{w: l.core.asyncWriter, level: level})
+	return l.decorate(zl, level, time.Now(), 0)
+}
+
+// decorate starts an event on zl with the level, timestamp and caller fields.
+func (l *Logger) decorate(zl zerolog.Logger, level zerolog.Level, t time.Time, pc uintptr) *zerolog.Event {
+	// Events are created without a zerolog level so zerolog's global gate
+	// never applies; the level is written by hand instead.
+	e := zl.Log().Str(zerolog.LevelFieldName, zerolog.LevelFieldMarshalFunc(level))
+
+	flags := l.core.flags
+	if ts, ok := timestamp(t, flags); ok {
+		e = e.Str(zerolog.TimestampFieldName, ts)
+	}
+	if pc != 0 && flags&(stdlog.Lshortfile|stdlog.Llongfile) != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		file := frame.File
+		if flags&stdlog.Lshortfile != 0 {
+			file = file[strings.LastIndexByte(file, '/')+1:]
+		}
+		e = e.Str(zerolog.CallerFieldName, fmt.Sprintf("%s:%d", file, frame.Line))
+	}
+	return e
+}
+
+// timestamp renders the event time according to the standard library's log
+// flags. Zero flags keep the default RFC 3339 timestamp.
+func timestamp(now time.Time, flags int) (string, bool) {
+	if flags&stdlog.LUTC != 0 {
+		now = now.UTC()
+	}
+	if flags == 0 {
+		return now.Format(time.RFC3339), true
+	}
+	if flags&(stdlog.Ldate|stdlog.Ltime|stdlog.Lmicroseconds) == 0 {
+		return "", false
+	}
+	if flags&stdlog.Lmicroseconds != 0 {
+		return now.Format("2006-01-02T15:04:05.000000Z07:00"), true
+	}
+	return now.Format(time.RFC3339), true
+}
+
+func (l *Logger) Trace(msg string) { l.newEvent(zerolog.TraceLevel).Msg(msg) }
+func (l *Logger) Info(msg string)  { l.newEvent(zerolog.InfoLevel).Msg(msg) }
+func (l *Logger) Warn(msg string)  { l.newEvent(zerolog.WarnLevel).Msg(msg) }
+func (l *Logger) Debug(msg string) { l.newEvent(zerolog.DebugLevel).Msg(msg) }
+func (l *Logger) Error(msg string) { l.newEvent(zerolog.ErrorLevel).Msg(msg) }
+
+// Fatal logs the message, flushes every sink and exits the process.
+func (l *Logger) Fatal(msg string) {
+	l.newEvent(zerolog.FatalLevel).Msg(msg)
+	_ = l.Close()
+	os.Exit(1)
+}
+
+func (l *Logger) Tracef(format string, args ...interface{}) {
+	l.newEvent(zerolog.TraceLevel).Msgf(format, args...)
+}
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.newEvent(zerolog.InfoLevel).Msgf(format, args...)
+}
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.newEvent(zerolog.WarnLevel).Msgf(format, args...)
+}
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.newEvent(zerolog.DebugLevel).Msgf(format, args...)
+}
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.newEvent(zerolog.ErrorLevel).Msgf(format, args...)
+}
+
+// Fatalf logs the formatted message, flushes every sink and exits the process.
+func (l *Logger) Fatalf(format string, args ...interface{}) {
+	l.newEvent(zerolog.FatalLevel).Msgf(format, args...)
+	_ = l.Close()
+	os.Exit(1)
+}
+
+// Close flushes pending events and closes every sink. Components share their
+// parent's sinks, so closing any of them closes them all.
+func (l *Logger) Close() error {
+	var err error
+	l.core.closeOnce.Do(func() {
+		l.levels.stopReverts()
+		if l.core.sampler != nil {
+			l.core.sampler.close()
+		}
+		if l.core.asyncWriter != nil {
+			l.core.asyncWriter.Close()
+		}
+		err = l.core.sinks.close()
+	})
+	return err
 }
 
 func (l *Logger) InfoEvent() *zerolog.Event {
-	return l.log.Info()
+	return l.newEvent(zerolog.InfoLevel)
+}
+
+func (l *Logger) DebugEvent() *zerolog.Event {
+	return l.newEvent(zerolog.DebugLevel)
+}
+
+func (l *Logger) WarnEvent() *zerolog.Event {
+	return l.newEvent(zerolog.WarnLevel)
+}
+
+func (l *Logger) ErrorEvent() *zerolog.Event {
+	return l.newEvent(zerolog.ErrorLevel)
 }
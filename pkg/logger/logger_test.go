@@ -0,0 +1,220 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spyder01/lilium-go/pkg/config"
+)
+
+func newFileLogger(t *testing.T, cfg config.LogConfig) (*Logger, string) {
+	t.Helper()
+	cfg.ToFile = true
+	cfg.FilePath = filepath.Join(t.TempDir(), "test.log")
+	l, err := NewLogger(&cfg)
+	if err != nil {
+		t.Fatalf("NewLogger returned error: %v", err)
+	}
+	return l, cfg.FilePath
+}
+
+func readLines(t *testing.T, l *Logger, path string) []string {
+	t.Helper()
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read log file: %v", err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestLogger_LevelsArePerInstance(t *testing.T) {
+	quiet, quietPath := newFileLogger(t, config.LogConfig{Level: "error"})
+	verbose, verbosePath := newFileLogger(t, config.LogConfig{Level: "trace"})
+
+	quiet.Info("hidden")
+	quiet.Error("shown")
+	verbose.Trace("trace shown")
+
+	if lines := readLines(t, quiet, quietPath); len(lines) != 1 || !strings.Contains(lines[0], "shown") {
+		t.Fatalf("expected only the error line, got %v", lines)
+	}
+
+	lines := readLines(t, verbose, verbosePath)
+	if len(lines) != 1 {
+		t.Fatalf("expected one trace line, got %v", lines)
+	}
+	var evt map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &evt); err != nil {
+		t.Fatalf("invalid JSON line %q: %v", lines[0], err)
+	}
+	if evt["level"] != "trace" {
+		t.Fatalf("expected level=trace, got %v", evt["level"])
+	}
+}
+
+func TestLogger_ComponentLevelOverride(t *testing.T) {
+	l, path := newFileLogger(t, config.LogConfig{
+		Level:  "info",
+		Levels: map[string]string{"eventbus": "debug", "http": "warn"},
+	})
+
+	l.Component("eventbus").Debug("bus debug")
+	l.Component("http").Info("http info")
+	l.Component("http").Component("access").Warn("access warn")
+	l.Debug("root debug")
+
+	lines := readLines(t, l, path)
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %v", len(lines), lines)
+	}
+	if !strings.Contains(lines[0], `"component":"eventbus"`) {
+		t.Errorf("expected eventbus component, got %s", lines[0])
+	}
+	if !strings.Contains(lines[1], `"component":"http.access"`) {
+		t.Errorf("expected http.access component, got %s", lines[1])
+	}
+}
+
+func TestLogger_LogfmtWithPrefix(t *testing.T) {
+	l, path := newFileLogger(t, config.LogConfig{FileFormat: "logfmt", Prefix: "[App] "})
+
+	l.Component("db").Info("connected to primary")
+
+	lines := readLines(t, l, path)
+	if len(lines) != 1 {
+		t.Fatalf("expected one line, got %v", lines)
+	}
+	line := lines[0]
+	if !strings.HasPrefix(line, "[App] time=") {
+		t.Errorf("expected prefix before the time field, got %q", line)
+	}
+	if !strings.Contains(line, `level=info component=db message="connected to primary"`) {
+		t.Errorf("unexpected logfmt line %q", line)
+	}
+}
+
+func TestNewLogger_RejectsUnknownLevelAndFormat(t *testing.T) {
+	if _, err := NewLogger(&config.LogConfig{Level: "loud"}); err == nil {
+		t.Error("expected error for unknown level")
+	}
+	if _, err := NewLogger(&config.LogConfig{ToStdout: true, StdoutFormat: "xml"}); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestLogger_SetLevelForReverts(t *testing.T) {
+	l, path := newFileLogger(t, config.LogConfig{Level: "info"})
+
+	l.SetComponentLevelFor("db", zerolog.DebugLevel, 20*time.Millisecond)
+	l.Component("db").Debug("while raised")
+
+	time.Sleep(60 * time.Millisecond)
+	l.Component("db").Debug("after revert")
+
+	if _, components := l.Levels(); len(components) != 0 {
+		t.Fatalf("expected override to be reverted, got %v", components)
+	}
+
+	lines := readLines(t, l, path)
+	joined := strings.Join(lines, "\n")
+	if !strings.Contains(joined, "while raised") || strings.Contains(joined, "after revert") {
+		t.Fatalf("unexpected output:\n%s", joined)
+	}
+	if strings.Count(joined, "log level changed") != 2 {
+		t.Fatalf("expected the change and its revert to be recorded:\n%s", joined)
+	}
+}
+
+func TestLogger_ToggleDebug(t *testing.T) {
+	l, _ := newFileLogger(t, config.LogConfig{Level: "warn"})
+	defer l.Close()
+
+	if got := l.ToggleDebug(); got != zerolog.DebugLevel {
+		t.Fatalf("expected debug, got %v", got)
+	}
+	if got := l.ToggleDebug(); got != zerolog.WarnLevel {
+		t.Fatalf("expected configured warn level back, got %v", got)
+	}
+
+	trace, _ := newFileLogger(t, config.LogConfig{Level: "trace"})
+	defer trace.Close()
+	if got := trace.ToggleDebug(); got != zerolog.DebugLevel {
+		t.Fatalf("expected debug from trace, got %v", got)
+	}
+	if got := trace.ToggleDebug(); got != zerolog.TraceLevel {
+		t.Fatalf("expected trace back, got %v", got)
+	}
+}
+
+func TestLevelHandler(t *testing.T) {
+	l, _ := newFileLogger(t, config.LogConfig{})
+	defer l.Close()
+	h := LevelHandler(l)
+
+	req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"level":"debug","component":"http"}`))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	var state levelState
+	if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if state.Level != "info" || state.Components["http"] != "debug" {
+		t.Fatalf("unexpected state %+v", state)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"level":"loud"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for bad level, got %d", rec.Code)
+	}
+}
+
+func TestLogger_ApplyConfig(t *testing.T) {
+	l, err := NewLogger(&config.LogConfig{
+		Level:  "info",
+		Levels: map[string]string{"http": "warn", "db": "debug"},
+	})
+	if err != nil {
+		t.Fatalf("NewLogger returned error: %v", err)
+	}
+	defer l.Close()
+	l.SetComponentLevel("cache", zerolog.ErrorLevel)
+
+	if err := l.ApplyConfig(&config.LogConfig{Level: "debug", Levels: map[string]string{"http": "error"}}); err != nil {
+		t.Fatalf("ApplyConfig returned error: %v", err)
+	}
+
+	base, components := l.Levels()
+	if base != zerolog.DebugLevel {
+		t.Fatalf("expected base level debug, got %s", base)
+	}
+	if components["http"] != zerolog.ErrorLevel {
+		t.Fatalf("expected http at error, got %v", components)
+	}
+	if _, ok := components["db"]; ok {
+		t.Fatalf("expected db override dropped with the config, got %v", components)
+	}
+	if components["cache"] != zerolog.ErrorLevel {
+		t.Fatalf("expected the API override for cache to survive, got %v", components)
+	}
+
+	if err := l.ApplyConfig(&config.LogConfig{Level: "loud"}); err == nil {
+		t.Fatal("expected an error for an unknown level")
+	}
+}
@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	defaultNetBufferSize = 1000
+	netDialTimeout       = 5 * time.Second
+	netMaxBackoff        = 5 * time.Second
+	netFlushTimeout      = 5 * time.Second
+)
+
+// netWriter ships messages to a network address from a background
+// goroutine. Each Write is sent as one message (one datagram for
+// connectionless networks). While the connection is down, up to bufferSize
+// messages are held and newer ones are dropped; the connection is re-dialed
+// with exponential backoff.
+type netWriter struct {
+	network string
+	address string
+
+	mu     sync.RWMutex // guards closed so Write never sends on a closed channel
+	closed bool
+	ch     chan []byte
+
+	abort chan struct{}
+	done  chan struct{}
+}
+
+func newNetWriter(network, address string, bufferSize int) *netWriter {
+	if bufferSize <= 0 {
+		bufferSize = defaultNetBufferSize
+	}
+	w := &netWriter{
+		network: network,
+		address: address,
+		ch:      make(chan []byte, bufferSize),
+		abort:   make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *netWriter) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return len(p), nil
+	}
+
+	cp := make([]byte, len(p))
+	copy(cp, p)
+
+	select {
+	case w.ch <- cp:
+	default:
+		// buffer full–drop message
+	}
+	return len(p), nil
+}
+
+// Close flushes buffered messages, giving up after netFlushTimeout, and
+// closes the connection.
+func (w *netWriter) Close() error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.ch)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+	case <-time.After(netFlushTimeout):
+		close(w.abort)
+		<-w.done
+	}
+	return nil
+}
+
+func (w *netWriter) run() {
+	defer close(w.done)
+
+	var conn net.Conn
+	defer func() {
+		if conn != nil {
+			_ = conn.Close()
+		}
+	}()
+
+	backoff := 100 * time.Millisecond
+	for msg := range w.ch {
+		for {
+			if conn == nil {
+				c, err := net.DialTimeout(w.network, w.address, netDialTimeout)
+				if err != nil {
+					select {
+					case <-time.After(backoff):
+					case <-w.abort:
+						return
+					}
+					backoff = min(backoff*2, netMaxBackoff)
+					continue
+				}
+				conn, backoff = c, 100*time.Millisecond
+			}
+
+			if _, err := conn.Write(msg); err != nil {
+				_ = conn.Close()
+				conn = nil
+				continue
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,413 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/spyder01/lilium-go/pkg/config"
+)
+
+const DefaultRedactMask = "[REDACTED]"
+
+// DefaultRedactKeys are masked whenever redaction is configured, unless
+// RedactConfig.NoDefaults is set. Keys match case-insensitively and ignore
+// '-' and '_', so "token" also covers "access_token" and "X-Auth-Token".
+var DefaultRedactKeys = []string{
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"api_key",
+	"apikey",
+	"authorization",
+	"cookie",
+}
+
+// cardPattern finds candidate payment card numbers: 13-19 digits, optionally
+// separated by spaces or dashes. Matches are only masked when they pass the
+// Luhn check, which keeps timestamps and other long numbers readable.
+var cardPattern = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+
+// cardValue matches a value that is a card number and nothing else.
+var cardValue = regexp.MustCompile(`^\d(?:[ -]?\d){12,18}$`)
+
+// cardKeys name JSON members that hold card numbers, matched like
+// DefaultRedactKeys. In JSON, other members are only checked for card
+// numbers when they are strings of card length, or the log message, so
+// numeric IDs and timestamps that pass the Luhn check stay readable.
+var cardKeys = []string{"card", "ccnum"}
+
+// messageKey is the member of a log event holding its free-text message.
+const messageKey = "message"
+
+// Redactor masks sensitive values in structured fields, free text, query
+// strings, headers and JSON documents. A nil *Redactor leaves everything
+// untouched.
+type Redactor struct {
+	keys     []string
+	patterns []*regexp.Regexp
+	inline   *regexp.Regexp // key=value / key: value pairs inside free text
+	cards    bool
+	mask     string
+
+	mu     sync.RWMutex
+	values []string // longest first, so a value containing another is masked whole
+}
+
+// shortValue is the length below which exact values are masked only as
+// whole words, so a short secret does not hide parts of unrelated text.
+const shortValue = 6
+
+// NewRedactor builds a Redactor from config. It returns nil when cfg is nil.
+func NewRedactor(cfg *config.RedactConfig) (*Redactor, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	r := &Redactor{mask: cfg.Mask, cards: !cfg.NoDefaults}
+	if r.mask == "" {
+		r.mask = DefaultRedactMask
+	}
+
+	keys := cfg.Keys
+	if !cfg.NoDefaults {
+		keys = append(append([]string(nil), DefaultRedactKeys...), keys...)
+	}
+
+	var quoted []string
+	for _, k := range keys {
+		if n := normalizeKey(k); n != "" {
+			r.keys = append(r.keys, n)
+			quoted = append(quoted, regexp.QuoteMeta(k))
+		}
+	}
+	for _, p := range cfg.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	r.AddValues(cfg.Values...)
+	if len(quoted) > 0 {
+		r.inline = regexp.MustCompile(`(?i)([\w-]*(?:` + strings.Join(quoted, "|") + `)[\w-]*)(\s*[=:]\s*)("[^"]*"|[^\s&,;"]+)`)
+	}
+
+	return r, nil
+}
+
+// AddValues masks values as exact text from now on, e.g. secrets that were
+// rotated by a config reload.
+func (r *Redactor) AddValues(values ...string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	seen := make(map[string]bool, len(r.values))
+	for _, v := range r.values {
+		seen[v] = true
+	}
+	for _, v := range values {
+		if v != "" && !seen[v] {
+			seen[v] = true
+			r.values = append(r.values, v)
+		}
+	}
+	sort.SliceStable(r.values, func(i, j int) bool { return len(r.values[i]) > len(r.values[j]) })
+}
+
+func normalizeKey(k string) string {
+	k = strings.ToLower(k)
+	k = strings.ReplaceAll(k, "-", "")
+	return strings.ReplaceAll(k, "_", "")
+}
+
+// SensitiveKey returns a matcher for DefaultRedactKeys plus the keys of cfg,
+// which may be nil. Unlike a Redactor it ignores NoDefaults, for places such
+// as config dumps where the default keys must always be masked.
+func SensitiveKey(cfg *config.RedactConfig) func(key string) bool {
+	r := &Redactor{}
+	keys := append([]string(nil), DefaultRedactKeys...)
+	if cfg != nil {
+		keys = append(keys, cfg.Keys...)
+	}
+	for _, k := range keys {
+		if n := normalizeKey(k); n != "" {
+			r.keys = append(r.keys, n)
+		}
+	}
+	return r.MatchKey
+}
+
+// MatchKey reports whether values stored under key must be masked.
+func (r *Redactor) MatchKey(key string) bool {
+	if r == nil {
+		return false
+	}
+	n := normalizeKey(key)
+	for _, k := range r.keys {
+		if strings.Contains(n, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// String masks pattern matches, sensitive key=value pairs and card numbers
+// in free text.
+func (r *Redactor) String(s string) string {
+	if r == nil {
+		return s
+	}
+	return r.text(s, r.cards)
+}
+
+func (r *Redactor) text(s string, cards bool) string {
+	r.mu.RLock()
+	for _, v := range r.values {
+		if len(v) < shortValue {
+			s = replaceWord(s, v, r.mask)
+		} else {
+			s = strings.ReplaceAll(s, v, r.mask)
+		}
+	}
+	r.mu.RUnlock()
+	if r.inline != nil {
+		s = r.inline.ReplaceAllString(s, "${1}${2}"+r.mask)
+	}
+	for _, re := range r.patterns {
+		s = re.ReplaceAllString(s, r.mask)
+	}
+	if cards {
+		s = cardPattern.ReplaceAllStringFunc(s, func(m string) string {
+			if luhnValid(m) {
+				return r.mask
+			}
+			return m
+		})
+	}
+	return s
+}
+
+// replaceWord replaces the occurrences of v in s that are not part of a
+// longer word.
+func replaceWord(s, v, mask string) string {
+	var b strings.Builder
+	last := 0
+	for i := 0; i < len(s); {
+		j := strings.Index(s[i:], v)
+		if j < 0 {
+			break
+		}
+		start, end := i+j, i+j+len(v)
+		before, _ := utf8.DecodeLastRuneInString(s[:start])
+		after, _ := utf8.DecodeRuneInString(s[end:])
+		if (start == 0 || !wordRune(before)) && (end == len(s) || !wordRune(after)) {
+			b.WriteString(s[last:start])
+			b.WriteString(mask)
+			last, i = end, end
+		} else {
+			i = start + 1
+		}
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func wordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// cardField reports whether JSON members named key hold card numbers.
+func cardField(key string) bool {
+	n := normalizeKey(key)
+	for _, k := range cardKeys {
+		if strings.Contains(n, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func luhnValid(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// Query masks the values of sensitive parameters in a raw query string.
+func (r *Redactor) Query(rawQuery string) string {
+	if r == nil || rawQuery == "" {
+		return rawQuery
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return r.String(rawQuery)
+	}
+	for key, vs := range values {
+		for i, v := range vs {
+			if r.MatchKey(key) {
+				vs[i] = r.mask
+			} else {
+				vs[i] = r.String(v)
+			}
+		}
+	}
+	return values.Encode()
+}
+
+// Header returns a copy of h with sensitive headers masked.
+func (r *Redactor) Header(h http.Header) http.Header {
+	out := h.Clone()
+	if r == nil {
+		return out
+	}
+	for key, vs := range out {
+		for i, v := range vs {
+			if r.MatchKey(key) {
+				vs[i] = r.mask
+			} else {
+				vs[i] = r.String(v)
+			}
+		}
+	}
+	return out
+}
+
+// JSON masks sensitive members of a JSON document while preserving member
+// order. Input that is not valid JSON is treated as free text.
+func (r *Redactor) JSON(p []byte) []byte {
+	if r == nil {
+		return p
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(p))
+	dec.UseNumber()
+
+	var out bytes.Buffer
+	out.Grow(len(p))
+	if err := r.rewriteJSON(dec, &out, "", false); err != nil {
+		return []byte(r.String(string(p)))
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		// Trailing data: not a single JSON value.
+		return []byte(r.String(string(p)))
+	}
+	if len(p) > 0 && p[len(p)-1] == '\n' {
+		out.WriteByte('\n')
+	}
+	return out.Bytes()
+}
+
+// rewriteJSON copies the next JSON value from dec to out; key is the name of
+// the member it belongs to.
+func (r *Redactor) rewriteJSON(dec *json.Decoder, out *bytes.Buffer, key string, masked bool) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			out.WriteByte('{')
+			for first := true; dec.More(); first = false {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				member, _ := keyTok.(string)
+				if !first {
+					out.WriteByte(',')
+				}
+				writeJSONString(out, member)
+				out.WriteByte(':')
+				if err := r.rewriteJSON(dec, out, member, masked || r.MatchKey(member)); err != nil {
+					return err
+				}
+			}
+			out.WriteByte('}')
+		case '[':
+			out.WriteByte('[')
+			for first := true; dec.More(); first = false {
+				if !first {
+					out.WriteByte(',')
+				}
+				if err := r.rewriteJSON(dec, out, key, masked); err != nil {
+					return err
+				}
+			}
+			out.WriteByte(']')
+		}
+		if t == '{' || t == '[' {
+			// consume the closing delimiter
+			if _, err := dec.Token(); err != nil {
+				return err
+			}
+		}
+
+	case string:
+		if masked {
+			writeJSONString(out, r.mask)
+		} else {
+			cards := r.cards && (key == messageKey || cardField(key) || cardValue.MatchString(t))
+			writeJSONString(out, r.text(t, cards))
+		}
+
+	case json.Number:
+		if masked {
+			writeJSONString(out, r.mask)
+		} else if s := r.text(t.String(), r.cards && cardField(key)); s != t.String() {
+			writeJSONString(out, s)
+		} else {
+			out.WriteString(t.String())
+		}
+
+	case bool:
+		if masked {
+			writeJSONString(out, r.mask)
+		} else {
+			fmt.Fprint(out, t)
+		}
+
+	case nil:
+		out.WriteString("null")
+	}
+	return nil
+}
+
+func writeJSONString(out *bytes.Buffer, s string) {
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	out.Truncate(out.Len() - 1) // Encode appends a newline
+}
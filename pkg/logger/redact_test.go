@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/spyder01/lilium-go/pkg/config"
+)
+
+func TestRedactor_JSONPreservesOrderAndMasksKeys(t *testing.T) {
+	r, err := NewRedactor(&config.RedactConfig{Keys: []string{"ssn"}})
+	if err != nil {
+		t.Fatalf("NewRedactor returned error: %v", err)
+	}
+
+	in := `{"user":"bob","password":"hunter2","nested":{"access_token":"abc","n":1},"ssn":123456789,"ok":true}`
+	want := `{"user":"bob","password":"[REDACTED]","nested":{"access_token":"[REDACTED]","n":1},"ssn":"[REDACTED]","ok":true}`
+	if got := string(r.JSON([]byte(in))); got != want {
+		t.Fatalf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestRedactor_TextQueryAndHeaders(t *testing.T) {
+	r, _ := NewRedactor(&config.RedactConfig{Mask: "***"})
+
+	msg := r.String("login password=hunter2 card 4111 1111 1111 1111 at 1700000000000")
+	if strings.Contains(msg, "hunter2") || strings.Contains(msg, "4111") {
+		t.Errorf("expected secrets masked, got %q", msg)
+	}
+	if !strings.Contains(msg, "1700000000000") {
+		t.Errorf("non-Luhn number should be kept, got %q", msg)
+	}
+
+	if q := r.Query("page=2&access_token=abc"); q != "access_token=%2A%2A%2A&page=2" {
+		t.Errorf("unexpected query %q", q)
+	}
+
+	h := r.Header(http.Header{"Authorization": {"Bearer x"}, "Accept": {"*/*"}})
+	if h.Get("Authorization") != "***" || h.Get("Accept") != "*/*" {
+		t.Errorf("unexpected headers %v", h)
+	}
+}
+
+func TestRedactor_JSONCardsOnlyInCardFields(t *testing.T) {
+	r, _ := NewRedactor(&config.RedactConfig{})
+
+	in := `{"message":"paid with 4111 1111 1111 1111","order_id":4111111111111111,"ts":"4111111111111111 ok",` +
+		`"ref":"4111-1111-1111-1111","card_number":4111111111111111}`
+	want := `{"message":"paid with [REDACTED]","order_id":4111111111111111,"ts":"4111111111111111 ok",` +
+		`"ref":"[REDACTED]","card_number":"[REDACTED]"}`
+	if got := string(r.JSON([]byte(in))); got != want {
+		t.Fatalf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestRedactor_NilIsNoop(t *testing.T) {
+	var r *Redactor
+	if r.String("password=x") != "password=x" || string(r.JSON([]byte(`{"token":1}`))) != `{"token":1}` {
+		t.Fatal("nil redactor should not modify input")
+	}
+}
+
+func TestLogger_RedactsBeforeSinks(t *testing.T) {
+	l, path := newFileLogger(t, config.LogConfig{
+		FileFormat: "logfmt",
+		Redact:     &config.RedactConfig{Patterns: []string{`sk_live_\w+`}},
+	})
+
+	l.InfoEvent().Str("token", "t0k3n").Msgf("charging with key sk_live_abc123")
+
+	lines := readLines(t, l, path)
+	if strings.Contains(lines[0], "t0k3n") || strings.Contains(lines[0], "sk_live_abc123") {
+		t.Fatalf("secret reached the sink: %s", lines[0])
+	}
+}
+
+func TestRedactor_MasksExactValues(t *testing.T) {
+	r, err := NewRedactor(&config.RedactConfig{NoDefaults: true, Values: []string{"hunter2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.String("connecting with hunter2 as admin"); got != "connecting with [REDACTED] as admin" {
+		t.Errorf("String = %q", got)
+	}
+	if got := string(r.JSON([]byte(`{"dsn":"postgres://app:hunter2@db"}`))); got != `{"dsn":"postgres://app:[REDACTED]@db"}` {
+		t.Errorf("JSON = %q", got)
+	}
+
+	r.AddValues("4821")
+	if got := r.String("pin=4821, order 948213, code:4821"); got != "pin=[REDACTED], order 948213, code:[REDACTED]" {
+		t.Errorf("short value String = %q", got)
+	}
+	if got := string(r.JSON([]byte(`{"pin":"4821","n":94821}`))); got != `{"pin":"[REDACTED]","n":94821}` {
+		t.Errorf("short value JSON = %q", got)
+	}
+}
+
+func TestLogger_RedactValuesAfterStart(t *testing.T) {
+	l, path := newFileLogger(t, config.LogConfig{FileFormat: "logfmt"})
+	l.RedactValues("rotated-s3cret")
+
+	l.Infof("connecting with rotated-s3cret")
+
+	lines := readLines(t, l, path)
+	if strings.Contains(lines[0], "rotated-s3cret") || !strings.Contains(lines[0], DefaultRedactMask) {
+		t.Fatalf("expected the added value to be masked: %s", lines[0])
+	}
+}
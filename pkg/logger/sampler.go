@@ -0,0 +1,181 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spyder01/lilium-go/pkg/config"
+)
+
+const levelCount = zerolog.FatalLevel - zerolog.TraceLevel + 1
+
+// sampler thins out high-volume logging. Per-level rates keep one in N
+// events of a level; burst/every limits identical messages (same component,
+// level and text) within a period, and suppressed counts are reported once
+// per period as a summary event.
+type sampler struct {
+	rates  [levelCount]uint64
+	counts [levelCount]atomic.Uint64
+
+	burst  uint64
+	every  uint64
+	period time.Duration
+
+	mu   sync.Mutex
+	keys map[sampleKey]*sampleState
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+type sampleKey struct {
+	level     zerolog.Level
+	component string
+	msg       string
+}
+
+type sampleState struct {
+	seen       uint64
+	suppressed uint64
+}
+
+func newSampler(cfg *config.SamplingConfig) (*sampler, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	s := &sampler{
+		burst:  uint64(cfg.Burst),
+		every:  uint64(cfg.Every),
+		period: cfg.Period,
+		keys:   make(map[sampleKey]*sampleState),
+	}
+	if s.period <= 0 {
+		s.period = time.Second
+	}
+	for name, rate := range cfg.Rates {
+		lvl, err := ParseLevel(name, zerolog.NoLevel)
+		if err != nil || lvl < zerolog.TraceLevel || lvl > zerolog.FatalLevel {
+			return nil, fmt.Errorf("rates: unknown log level %q", name)
+		}
+		s.rates[lvl-zerolog.TraceLevel] = uint64(rate)
+	}
+	return s, nil
+}
+
+// allow decides whether an event is written.
+func (s *sampler) allow(level zerolog.Level, component, msg string) bool {
+	i := level - zerolog.TraceLevel
+	if rate := s.rates[i]; rate > 1 && (s.counts[i].Add(1)-1)%rate != 0 {
+		return false
+	}
+	if s.burst == 0 && s.every == 0 {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := sampleKey{level: level, component: component, msg: msg}
+	st := s.keys[key]
+	if st == nil {
+		st = &sampleState{}
+		s.keys[key] = st
+	}
+	st.seen++
+	if st.seen <= s.burst {
+		return true
+	}
+	if s.every > 0 && (st.seen-s.burst-1)%s.every == 0 {
+		return true
+	}
+	st.suppressed++
+	return false
+}
+
+// start reports suppressed messages through emit once per period until
+// stopped.
+func (s *sampler) start(emit func(key sampleKey, suppressed uint64)) {
+	if s.burst == 0 && s.every == 0 {
+		return
+	}
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.period)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.flush(emit)
+			case <-s.stop:
+				s.flush(emit)
+				return
+			}
+		}
+	}()
+}
+
+// flush starts a new window and emits a summary for every key that had
+// messages suppressed in the previous one.
+func (s *sampler) flush(emit func(key sampleKey, suppressed uint64)) {
+	s.mu.Lock()
+	keys := s.keys
+	s.keys = make(map[sampleKey]*sampleState)
+	s.mu.Unlock()
+
+	for key, st := range keys {
+		if st.suppressed > 0 {
+			emit(key, st.suppressed)
+		}
+	}
+}
+
+func (s *sampler) close() {
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+}
+
+// samplingHook discards events the sampler rejects.
+type samplingHook struct {
+	s         *sampler
+	level     zerolog.Level
+	component string
+}
+
+func (h samplingHook) Run(e *zerolog.Event, _ zerolog.Level, msg string) {
+	if !h.s.allow(h.level, h.component, msg) {
+		e.Discard()
+	}
+}
+
+// SuccessSampler keeps one in N 2xx responses of a request log, the first
+// of every N; other statuses are always kept. It implements
+// AccessLogConfig.Sample2xx for both the access log and the request logging
+// middleware. It is safe for concurrent use.
+type SuccessSampler struct {
+	every uint64
+	count atomic.Uint64
+}
+
+// NewSuccessSampler returns a sampler that keeps one 2xx response in every;
+// zero and one keep them all.
+func NewSuccessSampler(every uint) *SuccessSampler {
+	return &SuccessSampler{every: uint64(every)}
+}
+
+// Keep reports whether a response with status should be logged.
+func (s *SuccessSampler) Keep(status int) bool {
+	if s == nil || s.every <= 1 || status < 200 || status >= 300 {
+		return true
+	}
+	return (s.count.Add(1)-1)%s.every == 0
+}
@@ -0,0 +1,116 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spyder01/lilium-go/pkg/config"
+)
+
+func TestSampler_BurstThenEvery(t *testing.T) {
+	l, path := newFileLogger(t, config.LogConfig{
+		Sampling: &config.SamplingConfig{Burst: 2, Every: 5, Period: time.Hour},
+	})
+
+	for i := 0; i < 12; i++ {
+		l.Error("db unreachable")
+	}
+	l.Error("different message")
+
+	lines := readLines(t, l, path)
+	written := 0
+	var summary string
+	for _, line := range lines {
+		switch {
+		case strings.Contains(line, `"message":"db unreachable"`):
+			written++
+		case strings.Contains(line, "suppressed"):
+			summary = line
+		}
+	}
+
+	// 2 from the burst, then the 3rd and 8th of the remaining 10.
+	if written != 4 {
+		t.Fatalf("expected 4 sampled lines, got %d:\n%s", written, strings.Join(lines, "\n"))
+	}
+	if !strings.Contains(summary, `"suppressed":8`) || !strings.Contains(summary, `"sampled_message":"db unreachable"`) {
+		t.Fatalf("expected summary of 8 suppressed messages, got %q", summary)
+	}
+	if !strings.Contains(strings.Join(lines, "\n"), "different message") {
+		t.Fatal("unrelated messages must not be sampled together")
+	}
+}
+
+func TestSampler_LevelRates(t *testing.T) {
+	l, path := newFileLogger(t, config.LogConfig{
+		Level:    "debug",
+		Sampling: &config.SamplingConfig{Rates: map[string]uint{"debug": 3}},
+	})
+
+	for i := 0; i < 9; i++ {
+		l.Debugf("tick %d", i)
+	}
+	l.Info("not sampled")
+
+	lines := readLines(t, l, path)
+	if len(lines) != 4 {
+		t.Fatalf("expected 3 debug lines and 1 info line, got %v", lines)
+	}
+}
+
+func TestSampler_WithoutSampling(t *testing.T) {
+	l, path := newFileLogger(t, config.LogConfig{
+		Sampling: &config.SamplingConfig{Burst: 1, Period: time.Hour},
+	})
+
+	access := l.Component("http").WithoutSampling()
+	for i := 0; i < 5; i++ {
+		access.Info("request completed")
+	}
+
+	if lines := readLines(t, l, path); len(lines) != 5 {
+		t.Fatalf("expected every access line, got %d", len(lines))
+	}
+}
+
+func TestSampler_LevelChangesAreNotSampled(t *testing.T) {
+	l, path := newFileLogger(t, config.LogConfig{
+		Sampling: &config.SamplingConfig{Burst: 1, Period: time.Hour, Rates: map[string]uint{"warn": 10}},
+	})
+
+	for _, lvl := range []zerolog.Level{zerolog.DebugLevel, zerolog.InfoLevel, zerolog.WarnLevel} {
+		l.SetLevel(lvl)
+	}
+
+	changes := 0
+	for _, line := range readLines(t, l, path) {
+		if strings.Contains(line, "log level changed") {
+			changes++
+		}
+	}
+	if changes != 3 {
+		t.Fatalf("expected every level change to be logged, got %d", changes)
+	}
+}
+
+func TestSuccessSampler(t *testing.T) {
+	s := NewSuccessSampler(3)
+	var kept []int
+	for i, status := range []int{200, 201, 204, 500, 200, 302, 200, 200} {
+		if s.Keep(status) {
+			kept = append(kept, i)
+		}
+	}
+	// The 1st and 4th 2xx responses (indexes 0 and 4) are kept, 500 and 302 always.
+	if fmt.Sprint(kept) != "[0 3 4 5]" {
+		t.Fatalf("unexpected kept responses %v", kept)
+	}
+
+	var none *SuccessSampler
+	if !NewSuccessSampler(1).Keep(200) || !NewSuccessSampler(0).Keep(200) || !none.Keep(200) {
+		t.Fatal("expected no sampling for 0, 1 and nil")
+	}
+}
@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spyder01/lilium-go/pkg/config"
+)
+
+func TestSyslogSink_UDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer pc.Close()
+
+	l, err := NewLogger(&config.LogConfig{Sinks: []config.SinkConfig{{
+		Type:     "syslog",
+		Network:  "udp",
+		Address:  pc.LocalAddr().String(),
+		Level:    "warn",
+		Facility: "local0",
+		AppName:  "billing api",
+	}}})
+	if err != nil {
+		t.Fatalf("NewLogger returned error: %v", err)
+	}
+
+	l.Info("below sink level")
+	l.Warn("disk almost full")
+	_ = l.Close()
+
+	buf := make([]byte, 4096)
+	_ = pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	msg := string(buf[:n])
+
+	// local0 (16) * 8 + warning (4) = 132
+	if !strings.HasPrefix(msg, "<132>1 ") {
+		t.Errorf("unexpected PRI/VERSION in %q", msg)
+	}
+	if !strings.Contains(msg, " billing_api ") || !strings.Contains(msg, `"message":"disk almost full"`) {
+		t.Errorf("unexpected message %q", msg)
+	}
+}
+
+func TestTCPSink_Reconnects(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	l, err := NewLogger(&config.LogConfig{Sinks: []config.SinkConfig{{
+		Type:    "tcp",
+		Address: ln.Addr().String(),
+	}}})
+	if err != nil {
+		t.Fatalf("NewLogger returned error: %v", err)
+	}
+	defer l.Close()
+
+	readLine := func(conn net.Conn) string {
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		return line
+	}
+
+	l.Info("first")
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	if line := readLine(conn); !strings.Contains(line, `"message":"first"`) {
+		t.Fatalf("unexpected line %q", line)
+	}
+	conn.Close()
+
+	// Writes after the peer went away fail eventually and trigger a redial.
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		c, err := ln.Accept()
+		if err == nil {
+			accepted <- c
+		}
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		l.Info("after reconnect")
+		select {
+		case c := <-accepted:
+			defer c.Close()
+			if line := readLine(c); !strings.Contains(line, "after reconnect") {
+				t.Fatalf("unexpected line %q", line)
+			}
+			return
+		case <-deadline:
+			t.Fatal("sink did not reconnect")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+func TestNewLogger_UnknownSinkType(t *testing.T) {
+	_, err := NewLogger(&config.LogConfig{Sinks: []config.SinkConfig{{Type: "kafka"}}})
+	if err == nil || !strings.Contains(err.Error(), "kafka") {
+		t.Fatalf("expected unknown sink error, got %v", err)
+	}
+}
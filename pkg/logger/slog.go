@@ -0,0 +1,294 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// Slog returns a *slog.Logger that writes through l, sharing its levels,
+// format and sinks.
+func (l *Logger) Slog() *slog.Logger {
+	return slog.New(NewSlogHandler(l))
+}
+
+// SlogHandler is a slog.Handler backed by a Logger.
+type SlogHandler struct {
+	l      *Logger
+	groups []string      // open groups, outermost first
+	attrs  [][]slog.Attr // attrs[0] are top level, attrs[i+1] belong to groups[i]
+}
+
+func NewSlogHandler(l *Logger) *SlogHandler {
+	return &SlogHandler{l: l, attrs: make([][]slog.Attr, 1)}
+}
+
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.l.Enabled(FromSlogLevel(level))
+}
+
+func (h *SlogHandler) Handle(_ context.Context, r slog.Record) error {
+	level := FromSlogLevel(r.Level)
+	if !h.l.Enabled(level) {
+		return nil
+	}
+
+	t := r.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	e := h.l.buildEvent(level, t, r.PC)
+
+	var recordAttrs []slog.Attr
+	r.Attrs(func(a slog.Attr) bool {
+		recordAttrs = append(recordAttrs, a)
+		return true
+	})
+
+	// Build the innermost group first and wrap it in its parents; groups
+	// that end up empty are dropped, as slog prescribes.
+	var child *zerolog.Event
+	childFields := 0
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		d := zerolog.Dict()
+		n := appendSlogAttrs(d, h.attrs[i+1])
+		if i == len(h.groups)-1 {
+			n += appendSlogAttrs(d, recordAttrs)
+		}
+		if childFields > 0 {
+			d.Dict(h.groups[i+1], child)
+			n++
+		}
+		child, childFields = d, n
+	}
+
+	appendSlogAttrs(e, h.attrs[0])
+	if len(h.groups) == 0 {
+		appendSlogAttrs(e, recordAttrs)
+	} else if childFields > 0 {
+		e.Dict(h.groups[0], child)
+	}
+
+	e.Msg(r.Message)
+	return nil
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := h.clone()
+	last := len(h2.attrs) - 1
+	h2.attrs[last] = append(append([]slog.Attr(nil), h2.attrs[last]...), attrs...)
+	return h2
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := h.clone()
+	h2.groups = append(h2.groups, name)
+	h2.attrs = append(h2.attrs, nil)
+	return h2
+}
+
+func (h *SlogHandler) clone() *SlogHandler {
+	return &SlogHandler{
+		l:      h.l,
+		groups: append([]string(nil), h.groups...),
+		attrs:  append([][]slog.Attr(nil), h.attrs...),
+	}
+}
+
+// appendSlogAttrs adds attrs to e and returns how many fields were written.
+func appendSlogAttrs(e *zerolog.Event, attrs []slog.Attr) int {
+	n := 0
+	for _, a := range attrs {
+		n += appendSlogAttr(e, a)
+	}
+	return n
+}
+
+func appendSlogAttr(e *zerolog.Event, a slog.Attr) int {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return 0
+	}
+
+	v := a.Value
+	switch v.Kind() {
+	case slog.KindString:
+		e.Str(a.Key, v.String())
+	case slog.KindInt64:
+		e.Int64(a.Key, v.Int64())
+	case slog.KindUint64:
+		e.Uint64(a.Key, v.Uint64())
+	case slog.KindFloat64:
+		e.Float64(a.Key, v.Float64())
+	case slog.KindBool:
+		e.Bool(a.Key, v.Bool())
+	case slog.KindDuration:
+		e.Dur(a.Key, v.Duration())
+	case slog.KindTime:
+		e.Time(a.Key, v.Time())
+	case slog.KindGroup:
+		attrs := v.Group()
+		if a.Key == "" {
+			// Groups without a key are inlined.
+			return appendSlogAttrs(e, attrs)
+		}
+		d := zerolog.Dict()
+		if appendSlogAttrs(d, attrs) == 0 {
+			return 0
+		}
+		e.Dict(a.Key, d)
+	default:
+		if err, ok := v.Any().(error); ok {
+			e.AnErr(a.Key, err)
+		} else {
+			e.Interface(a.Key, v.Any())
+		}
+	}
+	return 1
+}
+
+// FromSlogLevel maps a slog level onto the nearest zerolog level at or below
+// it. Levels under slog.LevelDebug become trace.
+func FromSlogLevel(level slog.Level) zerolog.Level {
+	switch {
+	case level < slog.LevelDebug:
+		return zerolog.TraceLevel
+	case level < slog.LevelInfo:
+		return zerolog.DebugLevel
+	case level < slog.LevelWarn:
+		return zerolog.InfoLevel
+	case level < slog.LevelError:
+		return zerolog.WarnLevel
+	default:
+		return zerolog.ErrorLevel
+	}
+}
+
+// ToSlogLevel is the inverse of FromSlogLevel. Trace maps to LevelDebug-4
+// and fatal to LevelError+4.
+func ToSlogLevel(level zerolog.Level) slog.Level {
+	switch level {
+	case zerolog.TraceLevel:
+		return slog.LevelDebug - 4
+	case zerolog.DebugLevel:
+		return slog.LevelDebug
+	case zerolog.InfoLevel:
+		return slog.LevelInfo
+	case zerolog.WarnLevel:
+		return slog.LevelWarn
+	case zerolog.ErrorLevel:
+		return slog.LevelError
+	case zerolog.FatalLevel:
+		return slog.LevelError + 4
+	case zerolog.PanicLevel:
+		return slog.LevelError + 8
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// SlogSink converts Lilium's JSON events back into slog records so an
+// application-supplied slog.Handler can act as a log destination. Install it
+// with Logger.AddSink.
+type SlogSink struct {
+	h slog.Handler
+}
+
+func NewSlogSink(h slog.Handler) *SlogSink {
+	return &SlogSink{h: h}
+}
+
+func (s *SlogSink) Write(p []byte) (int, error) {
+	return s.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (s *SlogSink) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	var evt map[string]any
+	d := json.NewDecoder(bytes.NewReader(p))
+	d.UseNumber()
+	if err := d.Decode(&evt); err != nil {
+		return 0, fmt.Errorf("cannot decode event: %w", err)
+	}
+
+	if level == zerolog.NoLevel {
+		if name, ok := evt[zerolog.LevelFieldName].(string); ok {
+			level, _ = ParseLevel(name, zerolog.InfoLevel)
+		}
+	}
+	slogLevel := ToSlogLevel(level)
+
+	ctx := context.Background()
+	if !s.h.Enabled(ctx, slogLevel) {
+		return len(p), nil
+	}
+
+	var t time.Time
+	if ts, ok := evt[zerolog.TimestampFieldName].(string); ok {
+		t, _ = time.Parse(time.RFC3339Nano, ts)
+	}
+	msg, _ := evt[zerolog.MessageFieldName].(string)
+	delete(evt, zerolog.LevelFieldName)
+	delete(evt, zerolog.TimestampFieldName)
+	delete(evt, zerolog.MessageFieldName)
+
+	r := slog.NewRecord(t, slogLevel, msg, 0)
+	r.AddAttrs(jsonToAttrs(evt)...)
+	if err := s.h.Handle(ctx, r); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close closes the wrapped handler when it is an io.Closer.
+func (s *SlogSink) Close() error {
+	if c, ok := s.h.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func jsonToAttrs(m map[string]any) []slog.Attr {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, jsonToAttr(k, m[k]))
+	}
+	return attrs
+}
+
+func jsonToAttr(key string, v any) slog.Attr {
+	switch vv := v.(type) {
+	case string:
+		return slog.String(key, vv)
+	case bool:
+		return slog.Bool(key, vv)
+	case json.Number:
+		if i, err := vv.Int64(); err == nil {
+			return slog.Int64(key, i)
+		}
+		f, _ := vv.Float64()
+		return slog.Float64(key, f)
+	case map[string]any:
+		return slog.Attr{Key: key, Value: slog.GroupValue(jsonToAttrs(vv)...)}
+	default:
+		return slog.Any(key, vv)
+	}
+}
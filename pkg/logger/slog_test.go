@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/spyder01/lilium-go/pkg/config"
+)
+
+func TestSlogHandler_AttrsGroupsAndLevels(t *testing.T) {
+	l, path := newFileLogger(t, config.LogConfig{Level: "debug"})
+
+	log := l.Slog().With("service", "billing").WithGroup("req")
+	log.Info("charged", "amount", 42, slog.Group("card", "brand", "visa"), "err", errors.New("declined"))
+	log.WithGroup("empty").Debug("no attrs")
+	log.Log(context.Background(), slog.LevelDebug-4, "dropped below debug")
+
+	lines := readLines(t, l, path)
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %v", lines)
+	}
+
+	var evt map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &evt); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if evt["service"] != "billing" || evt["message"] != "charged" || evt["level"] != "info" {
+		t.Fatalf("unexpected top-level fields: %v", evt)
+	}
+	req, ok := evt["req"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected req group, got %v", evt)
+	}
+	if req["amount"] != float64(42) || req["err"] != "declined" {
+		t.Errorf("unexpected req group: %v", req)
+	}
+	if card, ok := req["card"].(map[string]any); !ok || card["brand"] != "visa" {
+		t.Errorf("expected nested card group, got %v", req["card"])
+	}
+
+	if strings.Contains(lines[1], "empty") {
+		t.Errorf("empty group should be omitted: %s", lines[1])
+	}
+}
+
+func TestSlogSink_ForwardsToHandler(t *testing.T) {
+	l, _ := newFileLogger(t, config.LogConfig{Level: "trace"})
+
+	var buf bytes.Buffer
+	h := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	l.AddSink("slog", NewSlogSink(h), zerolog.DebugLevel)
+
+	l.Component("db").Warn("slow query")
+	l.Trace("filtered by sink level")
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected one record, got %v", lines)
+	}
+	var rec map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if rec["level"] != "WARN" || rec["msg"] != "slow query" || rec["component"] != "db" {
+		t.Fatalf("unexpected record: %v", rec)
+	}
+}
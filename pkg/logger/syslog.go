@@ -0,0 +1,169 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spyder01/lilium-go/pkg/config"
+)
+
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5,
+	"lpr": 6, "news": 7, "uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// syslogSeverity maps zerolog levels onto RFC 5424 severities.
+func syslogSeverity(level zerolog.Level) int {
+	switch level {
+	case zerolog.TraceLevel, zerolog.DebugLevel:
+		return 7 // debug
+	case zerolog.InfoLevel:
+		return 6 // informational
+	case zerolog.WarnLevel:
+		return 4 // warning
+	case zerolog.ErrorLevel:
+		return 3 // error
+	case zerolog.FatalLevel:
+		return 2 // critical
+	case zerolog.PanicLevel:
+		return 1 // alert
+	default:
+		return 5 // notice
+	}
+}
+
+// syslogWriter sends events as RFC 5424 messages. The event is rendered in
+// the sink's format and becomes the MSG part. TCP uses octet-counting
+// framing (RFC 6587), unix streams are newline-terminated and datagram
+// networks send one message per packet.
+type syslogWriter struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	format io.Writer // renders into buf
+
+	transport *netWriter
+	network   string
+	facility  int
+	hostname  string
+	appName   string
+	procID    string
+}
+
+func newSyslogWriter(cfg config.SinkConfig, opts formatOptions) (*syslogWriter, error) {
+	network := strings.ToLower(cfg.Network)
+	address := cfg.Address
+	switch network {
+	case "", "udp", "tcp":
+		if network == "" {
+			network = "udp"
+		}
+		if address == "" {
+			address = "localhost:514"
+		}
+	case "unix", "unixgram":
+		if address == "" {
+			address = "/dev/log"
+		}
+	default:
+		return nil, fmt.Errorf("unknown syslog network %q", cfg.Network)
+	}
+
+	facilityName := strings.ToLower(cfg.Facility)
+	if facilityName == "" {
+		facilityName = "local0"
+	}
+	facility, ok := syslogFacilities[facilityName]
+	if !ok {
+		return nil, fmt.Errorf("unknown syslog facility %q", cfg.Facility)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	appName := cfg.AppName
+	if appName == "" {
+		appName = filepath.Base(os.Args[0])
+	}
+
+	w := &syslogWriter{
+		network:  network,
+		facility: facility,
+		hostname: syslogField(hostname, 255),
+		appName:  syslogField(appName, 48),
+		procID:   strconv.Itoa(os.Getpid()),
+	}
+
+	opts.noColor = true
+	if w.format, err = newFormatWriter(&w.buf, formatOrJSON(cfg.Format), opts); err != nil {
+		return nil, err
+	}
+	w.transport = newNetWriter(network, address, cfg.BufferSize)
+	return w, nil
+}
+
+// syslogField makes s a valid header field: printable ASCII without spaces,
+// at most limit characters, "-" when empty.
+func syslogField(s string, limit int) string {
+	s = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, s)
+	if len(s) > limit {
+		s = s[:limit]
+	}
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func (w *syslogWriter) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (w *syslogWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf.Reset()
+	if _, err := w.format.Write(p); err != nil {
+		return 0, err
+	}
+	msg := bytes.TrimRight(w.buf.Bytes(), "\n")
+
+	pri := w.facility*8 + syslogSeverity(level)
+	header := fmt.Sprintf("<%d>1 %s %s %s %s - - ",
+		pri, time.Now().Format("2006-01-02T15:04:05.000000Z07:00"), w.hostname, w.appName, w.procID)
+
+	var frame []byte
+	switch w.network {
+	case "tcp":
+		frame = fmt.Appendf(nil, "%d %s%s", len(header)+len(msg), header, msg)
+	case "unix":
+		frame = fmt.Appendf(nil, "%s%s\n", header, msg)
+	default:
+		frame = fmt.Appendf(nil, "%s%s", header, msg)
+	}
+
+	if _, err := w.transport.Write(frame); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *syslogWriter) Close() error {
+	return w.transport.Close()
+}
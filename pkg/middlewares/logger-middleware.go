@@ -1,60 +1,84 @@
 package middlewares
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/spyder01/lilium-go/pkg/config"
 	"github.com/spyder01/lilium-go/pkg/core"
 	"github.com/spyder01/lilium-go/pkg/logger"
 )
 
-type responseRecorder struct {
-	http.ResponseWriter
-	status int
-	size   int
-}
-
-func (rr *responseRecorder) WriteHeader(code int) {
-	rr.status = code
-	rr.ResponseWriter.WriteHeader(code)
+func RequestLoggingMiddleware(l *logger.Logger) core.Middleware {
+	return RequestLoggingMiddlewareWithConfig(l, nil)
 }
 
-func (rr *responseRecorder) Write(p []byte) (int, error) {
-	if rr.status == 0 {
-		rr.status = http.StatusOK
+// RequestLoggingMiddlewareWithConfig logs every request like
+// RequestLoggingMiddleware and, depending on cfg, its query string and body.
+// Both pass through the logger's redactor before they are written.
+//
+// Access logs bypass LogConfig.Sampling: every non-2xx response is logged,
+// and cfg.Sample2xx keeps one in N successful ones.
+func RequestLoggingMiddlewareWithConfig(l *logger.Logger, cfg *config.AccessLogConfig) core.Middleware {
+	l = l.Component("http").WithoutSampling()
+	if cfg == nil {
+		cfg = &config.AccessLogConfig{}
 	}
-	n, err := rr.ResponseWriter.Write(p)
-	rr.size += n
-	return n, err
-}
+	redactor := l.Redactor()
+	successes := logger.NewSuccessSampler(cfg.Sample2xx)
 
-func RequestLoggingMiddleware(l *logger.Logger) core.Middleware {
 	return func(next core.HandlerFunc) core.HandlerFunc {
 		return func(c *core.RequestContext) error {
 			start := time.Now()
 
-			// Wrap the ResponseWriter to capture status + size
-			rr := &responseRecorder{ResponseWriter: c.Res}
+			var body []byte
+			if cfg.LogBody && c.Req.Body != nil {
+				body = captureBody(c.Req, cfg.MaxBodySize)
+			}
+
+			// Capture status + size, sharing any recorder installed upstream
+			rr := core.CaptureResponse(c.Res)
 			c.Res = rr
 
 			err := next(c)
 
 			duration := time.Since(start)
-			status := rr.status
-			if status == 0 {
-				status = http.StatusOK
+			status := rr.Status()
+
+			if !successes.Keep(status) {
+				return err
 			}
 
 			// Logging fields
 			event := l.InfoEvent().
 				Str("method", c.Method()).
-				Str("path", c.Path()).
+				Str("path", c.Path())
+
+			if cfg.LogQuery && c.Req.URL.RawQuery != "" {
+				event = event.Str("query", redactor.Query(c.Req.URL.RawQuery))
+			}
+
+			event = event.
 				Int("status", status).
-				Int("size", rr.size).
+				Int("size", rr.Size()).
 				Dur("duration", duration).
 				Str("ip", c.ClientIP()).
 				Str("user_agent", c.Req.UserAgent())
 
+			if len(body) > 0 {
+				// Truncated JSON is no longer valid and is logged as text.
+				masked := redactor.JSON(body)
+				if strings.Contains(c.Req.Header.Get("Content-Type"), "json") && json.Valid(masked) {
+					event = event.RawJSON("body", masked)
+				} else {
+					event = event.Str("body", redactor.String(string(body)))
+				}
+			}
+
 			if err != nil {
 				event = event.Err(err)
 			}
@@ -65,3 +89,17 @@ func RequestLoggingMiddleware(l *logger.Logger) core.Middleware {
 		}
 	}
 }
+
+// captureBody reads up to limit bytes of the request body and puts them back
+// in front of the unread remainder so handlers still see the full body.
+func captureBody(req *http.Request, limit int) []byte {
+	if limit <= 0 {
+		limit = 4096
+	}
+	head, _ := io.ReadAll(io.LimitReader(req.Body, int64(limit)))
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), req.Body), req.Body}
+	return head
+}
@@ -1,22 +1,200 @@
 package env
 
 import (
+	"fmt"
 	"os"
-	"regexp"
+	"strings"
 )
 
-var envPattern = regexp.MustCompile(`\$\{([^:}]+)(:([^}]+))?\}`)
-
+// ExpandEnvWithDefault replaces references in s with their values:
+//
+//	${VAR}              value of VAR, empty when unset
+//	${VAR:default}      default when VAR is unset
+//	${VAR:-default}     default when VAR is unset or empty (POSIX)
+//	${VAR:?message}     error when VAR is unset or empty
+//	${scheme:ref}       value from a registered resolver, e.g. ${file:/run/secrets/x}
+//	$${text}            the literal text ${text}
+//
+// Defaults and refs may nest references: ${A:${B:x}}. References that fail
+// to resolve become empty strings; use Expand to see the error.
 func ExpandEnvWithDefault(s string) string {
-	return envPattern.ReplaceAllStringFunc(s, func(sub string) string {
-		matches := envPattern.FindStringSubmatch(sub)
+	out, _, _ := expand(s, true, ExpandOptions{})
+	return out
+}
+
+// Expand works like ExpandEnvWithDefault but stops at the first reference
+// that cannot be resolved. secret reports whether any part of the result
+// came from a resolver other than env, so callers can redact it.
+func Expand(s string) (value string, secret bool, err error) {
+	return expand(s, false, ExpandOptions{})
+}
+
+// ExpandOptions changes how ExpandWith looks up variables.
+type ExpandOptions struct {
+	// Lookup replaces os.LookupEnv for ${VAR} references. ${env:VAR} still
+	// reads the process environment.
+	Lookup func(name string) (string, bool)
+
+	// Bare also expands $VAR without braces, as shells and dotenv files do;
+	// $$ is then a literal $.
+	Bare bool
+}
+
+// ExpandWith works like Expand with the lookup and syntax set by opts.
+func ExpandWith(s string, opts ExpandOptions) (value string, secret bool, err error) {
+	return expand(s, false, opts)
+}
+
+// RequiredError reports a ${VAR:?message} reference whose variable is unset
+// or empty.
+type RequiredError struct {
+	Name    string
+	Message string
+}
+
+func (e *RequiredError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("required variable %s is not set", e.Name)
+	}
+	return fmt.Sprintf("%s: %s", e.Name, e.Message)
+}
+
+type expander struct {
+	lenient bool
+	secret  bool
+	opts    ExpandOptions
+}
+
+func expand(s string, lenient bool, opts ExpandOptions) (string, bool, error) {
+	if !strings.Contains(s, "${") && !(opts.Bare && strings.Contains(s, "$")) {
+		return s, false, nil
+	}
+	if opts.Lookup == nil {
+		opts.Lookup = os.LookupEnv
+	}
+	e := &expander{lenient: lenient, opts: opts}
+	out, err := e.expand(s)
+	if err != nil {
+		return s, false, err
+	}
+	return out, e.secret, nil
+}
 
-		key := matches[1]
-		def := matches[3]
+func (e *expander) expand(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		switch {
+		case strings.HasPrefix(s[i:], "$${"):
+			b.WriteString("${")
+			i += 3
+		case strings.HasPrefix(s[i:], "${"):
+			end := closingBrace(s, i+2)
+			if end < 0 {
+				if e.lenient {
+					b.WriteString(s[i:])
+					return b.String(), nil
+				}
+				return "", fmt.Errorf("unterminated reference %q", s[i:])
+			}
+			val, err := e.reference(s[i+2 : end])
+			if err != nil {
+				if !e.lenient {
+					return "", err
+				}
+				val = ""
+			}
+			b.WriteString(val)
+			i = end + 1
+		case e.opts.Bare && strings.HasPrefix(s[i:], "$$"):
+			b.WriteByte('$')
+			i += 2
+		case e.opts.Bare && s[i] == '$' && nameLen(s[i+1:]) > 0:
+			n := nameLen(s[i+1:])
+			val, _ := e.opts.Lookup(s[i+1 : i+1+n])
+			b.WriteString(val)
+			i += 1 + n
+		default:
+			b.WriteByte(s[i])
+			i++
+		}
+	}
+	return b.String(), nil
+}
+
+// closingBrace returns the index of the '}' that closes the reference whose
+// body starts at start, skipping nested references.
+func closingBrace(s string, start int) int {
+	depth := 0
+	for i := start; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "${"):
+			depth++
+			i++
+		case s[i] == '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// nameLen returns the length of the variable name at the start of s.
+func nameLen(s string) int {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9' {
+			continue
+		}
+		return i
+	}
+	return len(s)
+}
+
+// reference resolves the body of one ${...}.
+func (e *expander) reference(body string) (string, error) {
+	name, rest, hasRest := strings.Cut(body, ":")
 
-		if val, ok := os.LookupEnv(key); ok {
-			return val
+	if r, ok := lookupResolver(name); ok {
+		ref, err := e.expand(rest)
+		if err != nil {
+			return "", err
+		}
+		val, err := r.Resolve(ref)
+		if err != nil {
+			return "", &ResolveError{Scheme: name, Ref: ref, Err: err}
+		}
+		if name != "env" {
+			e.secret = true
+		}
+		return val, nil
+	}
+
+	val, set := e.opts.Lookup(name)
+	if !hasRest {
+		return val, nil
+	}
+
+	switch {
+	case strings.HasPrefix(rest, "-"):
+		if set && val != "" {
+			return val, nil
+		}
+		return e.expand(rest[1:])
+	case strings.HasPrefix(rest, "?"):
+		if set && val != "" {
+			return val, nil
+		}
+		msg, err := e.expand(rest[1:])
+		if err != nil {
+			return "", err
+		}
+		return "", &RequiredError{Name: name, Message: msg}
+	default:
+		if set {
+			return val, nil
 		}
-		return def
-	})
+		return e.expand(rest)
+	}
 }
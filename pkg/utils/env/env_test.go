@@ -1,6 +1,7 @@
 package env
 
 import (
+	"errors"
 	"os"
 	"testing"
 )
@@ -69,3 +70,84 @@ func TestExpandEnvWithDefault_NoVariables(t *testing.T) {
 		t.Errorf("expected %q, got %q", expected, result)
 	}
 }
+
+func TestExpandEnvWithDefault_PosixDefault(t *testing.T) {
+	t.Setenv("EMPTY", "")
+	os.Unsetenv("UNSET")
+
+	cases := map[string]string{
+		"${EMPTY:-fallback}": "fallback",
+		"${UNSET:-fallback}": "fallback",
+		"${EMPTY:fallback}":  "", // plain defaults only apply when unset
+	}
+	for in, want := range cases {
+		if got := ExpandEnvWithDefault(in); got != want {
+			t.Errorf("ExpandEnvWithDefault(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestExpand_Required(t *testing.T) {
+	os.Unsetenv("DB_URL")
+
+	_, _, err := Expand("${DB_URL:?set DB_URL to the database DSN}")
+	var rerr *RequiredError
+	if !errors.As(err, &rerr) || rerr.Name != "DB_URL" || rerr.Message != "set DB_URL to the database DSN" {
+		t.Fatalf("expected a RequiredError, got %v", err)
+	}
+
+	t.Setenv("DB_URL", "postgres://db")
+	if got, _, err := Expand("${DB_URL:?required}"); err != nil || got != "postgres://db" {
+		t.Fatalf("expected the set value, got %q err=%v", got, err)
+	}
+}
+
+func TestExpand_EscapeAndNesting(t *testing.T) {
+	os.Unsetenv("A")
+	os.Unsetenv("B")
+	t.Setenv("C", "from-c")
+
+	cases := map[string]string{
+		"$${HOME} costs $5":     "${HOME} costs $5",
+		"${A:${B:inner}}":       "inner",
+		"${A:${C}}":             "from-c",
+		"${A:-${B:-x}-suffix}":  "x-suffix",
+		"pre-${A:a:b:c}-post":   "pre-a:b:c-post",
+		"${A:{\"json\": true}}": "{\"json\": true}",
+	}
+	for in, want := range cases {
+		got, _, err := Expand(in)
+		if err != nil || got != want {
+			t.Errorf("Expand(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+
+	if _, _, err := Expand("${UNTERMINATED"); err == nil {
+		t.Error("expected an error for an unterminated reference")
+	}
+}
+
+func TestExpandWith_LookupAndBare(t *testing.T) {
+	vars := map[string]string{"HOST": "db", "PORT": "5432"}
+	lookup := func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+
+	cases := map[string]string{
+		"postgres://$HOST:${PORT}/app": "postgres://db:5432/app",
+		"${MISSING:-fallback}":         "fallback",
+		"costs $$HOST and $$5 or $5":   "costs $HOST and $5 or $5",
+		"$HOST_NAME":                   "",
+	}
+	for in, want := range cases {
+		got, _, err := ExpandWith(in, ExpandOptions{Lookup: lookup, Bare: true})
+		if err != nil || got != want {
+			t.Errorf("ExpandWith(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+
+	if got, _, _ := ExpandWith("$HOST", ExpandOptions{Lookup: lookup}); got != "$HOST" {
+		t.Errorf("expected bare references to stay without Bare, got %q", got)
+	}
+}
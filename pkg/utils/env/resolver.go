@@ -0,0 +1,107 @@
+package env
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Resolver looks up the value behind a ${scheme:ref} reference.
+type Resolver interface {
+	Resolve(ref string) (string, error)
+}
+
+// ResolverFunc adapts a function to Resolver.
+type ResolverFunc func(ref string) (string, error)
+
+func (f ResolverFunc) Resolve(ref string) (string, error) {
+	return f(ref)
+}
+
+// ResolveError reports a reference that could not be resolved.
+type ResolveError struct {
+	Scheme string
+	Ref    string
+	Err    error
+}
+
+// Error names the variable of env and the path of file references. Other
+// references are left out, since for inline schemes such as base64 the
+// reference is the secret itself.
+func (e *ResolveError) Error() string {
+	switch e.Scheme {
+	case "env", "file":
+		return fmt.Sprintf("cannot resolve ${%s:%s}: %v", e.Scheme, e.Ref, e.Err)
+	}
+	return fmt.Sprintf("cannot resolve ${%s:...}: %v", e.Scheme, e.Err)
+}
+
+func (e *ResolveError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	resolverMu sync.RWMutex
+	resolvers  = map[string]Resolver{
+		"env":    ResolverFunc(resolveEnv),
+		"file":   ResolverFunc(resolveFile),
+		"base64": ResolverFunc(resolveBase64),
+	}
+)
+
+// RegisterResolver makes ${scheme:ref} resolve through r, replacing any
+// resolver already registered for scheme. Values from every scheme except
+// env are treated as secrets.
+func RegisterResolver(scheme string, r Resolver) {
+	resolverMu.Lock()
+	resolvers[scheme] = r
+	resolverMu.Unlock()
+}
+
+// UnregisterResolver removes the resolver for scheme.
+func UnregisterResolver(scheme string) {
+	resolverMu.Lock()
+	delete(resolvers, scheme)
+	resolverMu.Unlock()
+}
+
+func lookupResolver(scheme string) (Resolver, bool) {
+	resolverMu.RLock()
+	defer resolverMu.RUnlock()
+	r, ok := resolvers[scheme]
+	return r, ok
+}
+
+// resolveEnv handles ${env:VAR}, which fails when VAR is unset, and
+// ${env:VAR:default}.
+func resolveEnv(ref string) (string, error) {
+	name, def, hasDefault := strings.Cut(ref, ":")
+	if val, ok := os.LookupEnv(name); ok {
+		return val, nil
+	}
+	if hasDefault {
+		return def, nil
+	}
+	return "", fmt.Errorf("environment variable %s is not set", name)
+}
+
+// resolveFile reads a secret file such as /run/secrets/db_password,
+// dropping one trailing newline.
+func resolveFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	s := strings.TrimSuffix(string(data), "\n")
+	return strings.TrimSuffix(s, "\r"), nil
+}
+
+func resolveBase64(encoded string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,73 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpand_BuiltinResolvers(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "db_password")
+	if err := os.WriteFile(secretFile, []byte("s3cr3t\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DB_USER", "app")
+
+	cases := []struct {
+		in, want string
+		secret   bool
+	}{
+		{"${file:" + secretFile + "}", "s3cr3t", true},
+		{"${base64:aGVsbG8=}", "hello", true},
+		{"${env:DB_USER}", "app", false},
+		{"${env:MISSING_VAR:fallback}", "fallback", false},
+		{"${DB_USER}:${file:" + secretFile + "}", "app:s3cr3t", true},
+	}
+	for _, tc := range cases {
+		got, secret, err := Expand(tc.in)
+		if err != nil {
+			t.Fatalf("Expand(%q) returned error: %v", tc.in, err)
+		}
+		if got != tc.want || secret != tc.secret {
+			t.Errorf("Expand(%q) = %q, secret=%v; want %q, secret=%v", tc.in, got, secret, tc.want, tc.secret)
+		}
+	}
+}
+
+func TestExpand_ReportsErrors(t *testing.T) {
+	for _, in := range []string{"${file:/does/not/exist}", "${base64:!!!}", "${env:SURELY_UNSET_VAR}"} {
+		_, _, err := Expand(in)
+		var rerr *ResolveError
+		if !errors.As(err, &rerr) {
+			t.Errorf("Expand(%q): expected a ResolveError, got %v", in, err)
+		}
+	}
+
+	_, _, err := Expand("${base64:c2VjcmV0!}")
+	if err == nil || strings.Contains(err.Error(), "c2VjcmV0") || !strings.Contains(err.Error(), "${base64:...}") {
+		t.Errorf("expected the inline reference to be left out, got %v", err)
+	}
+	if _, _, err := Expand("${file:/does/not/exist}"); !strings.Contains(fmt.Sprint(err), "${file:/does/not/exist}") {
+		t.Errorf("expected the file path in the error, got %v", err)
+	}
+
+	// The lenient variant keeps its old behaviour and yields an empty value.
+	if got := ExpandEnvWithDefault("x${file:/does/not/exist}y"); got != "xy" {
+		t.Errorf("expected failed lookups to expand to empty, got %q", got)
+	}
+}
+
+func TestRegisterResolver(t *testing.T) {
+	RegisterResolver("upper", ResolverFunc(func(ref string) (string, error) {
+		return ref + "!", nil
+	}))
+	defer UnregisterResolver("upper")
+
+	got, secret, err := Expand("${upper:hi}")
+	if err != nil || got != "hi!" || !secret {
+		t.Fatalf("expected a custom resolver to be used, got %q secret=%v err=%v", got, secret, err)
+	}
+}